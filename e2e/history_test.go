@@ -0,0 +1,205 @@
+package e2e_test
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type historyStation struct {
+	Name       string `json:"name"`
+	Outcome    string `json:"outcome"`
+	ExitCode   int    `json:"exit_code"`
+	Attempts   int    `json:"attempts"`
+	DurationMS int64  `json:"duration_ms"`
+	Commit     string `json:"commit"`
+	Agent      string `json:"agent"`
+	Usage      *struct {
+		CostUSD             float64 `json:"cost_usd"`
+		InputTokens         int64   `json:"input_tokens"`
+		OutputTokens        int64   `json:"output_tokens"`
+		CacheReadTokens     int64   `json:"cache_read_tokens"`
+		CacheCreationTokens int64   `json:"cache_creation_tokens"`
+		Turns               int     `json:"turns"`
+		Result              string  `json:"result"`
+		ErrorSubtype        string  `json:"error_subtype"`
+	} `json:"usage"`
+	Summary string `json:"summary"`
+}
+
+type historyRun struct {
+	ID       string           `json:"id"`
+	Commit   string           `json:"commit"`
+	Start    string           `json:"start"`
+	End      string           `json:"end"`
+	Stations []historyStation `json:"stations"`
+
+	Interrupted bool `json:"interrupted"`
+}
+
+// readHistoryJSON runs line history with the given extra args and parses the JSON output.
+func readHistoryJSON(dir string, args ...string) []historyRun {
+	out := lineOK(dir, append([]string{"history", "--json"}, args...)...)
+	var runs []historyRun
+	ExpectWithOffset(1, json.Unmarshal([]byte(out), &runs)).To(Succeed(), out)
+	return runs
+}
+
+var _ = Describe("line history", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+	})
+
+	// HIST-1: Runs are recorded with trigger commit and per-station results
+	// HIST-3: JSON output
+	It("records each run with per-station outcomes [HIST-1, HIST-3]", func() {
+		failingAgent := writeFailingMockAgent(dir)
+		agentScript := writeMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: cleanup
+    command: `+failingAgent+`
+    prompt: "Clean up code"
+`)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+		trigger := git(dir, "rev-parse", "HEAD")
+
+		runs := readHistoryJSON(dir)
+		Expect(runs).To(HaveLen(1))
+		run := runs[0]
+		Expect(run.ID).NotTo(BeEmpty())
+		Expect(run.Commit).To(Equal(trigger))
+		Expect(run.Start).NotTo(BeEmpty())
+		Expect(run.End).NotTo(BeEmpty())
+
+		Expect(run.Stations).To(HaveLen(2))
+		Expect(run.Stations[0].Name).To(Equal("review"))
+		Expect(run.Stations[0].Outcome).To(Equal("succeeded"))
+		Expect(run.Stations[0].ExitCode).To(Equal(0))
+		Expect(run.Stations[0].Commit).To(Equal(git(dir, "rev-parse", "line/stn/review")))
+
+		Expect(run.Stations[1].Name).To(Equal("cleanup"))
+		Expect(run.Stations[1].Outcome).To(Equal("failed"))
+		Expect(run.Stations[1].ExitCode).To(Equal(1))
+	})
+
+	// HIST-2: Newest first, filterable by station and outcome
+	It("lists runs newest first with station and outcome filters [HIST-2]", func() {
+		agentScript := writeMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: cleanup
+    prompt: "Clean up code"
+`)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "first change")
+		first := git(dir, "rev-parse", "HEAD")
+
+		writeFile(dir, "extra.go", "package main\n")
+		gitCommit(dir, "second change")
+		second := git(dir, "rev-parse", "HEAD")
+
+		runs := readHistoryJSON(dir)
+		Expect(runs).To(HaveLen(2))
+		Expect(runs[0].Commit).To(Equal(second))
+		Expect(runs[1].Commit).To(Equal(first))
+
+		byStation := readHistoryJSON(dir, "--station", "cleanup")
+		Expect(byStation).To(HaveLen(2))
+		for _, r := range byStation {
+			Expect(r.Stations).To(HaveLen(1))
+			Expect(r.Stations[0].Name).To(Equal("cleanup"))
+		}
+
+		Expect(readHistoryJSON(dir, "--outcome", "failed")).To(BeEmpty())
+		Expect(readHistoryJSON(dir, "-n", "1")).To(HaveLen(1))
+
+		out := lineOK(dir, "history")
+		Expect(out).To(ContainSubstring(second[:7]))
+		Expect(out).To(ContainSubstring("review"))
+		Expect(out).To(ContainSubstring("succeeded"))
+	})
+
+	// HIST-4: A run stopped part-way is recorded by the next run
+	It("records runs stopped by a newer run as interrupted [HIST-4]", func() {
+		agentScript := writeMockAgent(dir)
+		slowAgent := writeSlowMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: cleanup
+    command: `+slowAgent+`
+    prompt: "Clean up code"
+`)
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+
+		cmd := exec.Command(binaryPath, "run")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		Expect(cmd.Start()).To(Succeed())
+		DeferCleanup(func() {
+			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+			_ = cmd.Wait()
+		})
+
+		// review has finished once cleanup's agent is running
+		Eventually(func() bool {
+			return fileExists(dir, ".line/stations/cleanup.pid")
+		}, 10*time.Second, 100*time.Millisecond).Should(BeTrue())
+
+		// A newer run stops the first one
+		out := lineOK(dir, "run", "--station", "review")
+		Expect(out).To(ContainSubstring("terminating previous run"))
+
+		runs := readHistoryJSON(dir)
+		Expect(runs).To(HaveLen(2))
+		Expect(runs[0].Interrupted).To(BeFalse())
+		stopped := runs[1]
+		Expect(stopped.Interrupted).To(BeTrue())
+		Expect(stopped.Stations).To(HaveLen(2))
+		Expect(stopped.Stations[0].Name).To(Equal("review"))
+		Expect(stopped.Stations[0].Outcome).To(Equal("succeeded"))
+		Expect(stopped.Stations[1].Name).To(Equal("cleanup"))
+		Expect(stopped.Stations[1].Outcome).To(Equal("interrupted"))
+
+		Expect(readHistoryJSON(dir, "--outcome", "interrupted")).To(HaveLen(1))
+		Expect(lineOK(dir, "history")).To(ContainSubstring("interrupted"))
+	})
+})
@@ -0,0 +1,230 @@
+package e2e_test
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line logs", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+	})
+
+	// LOG-1: Agent output is captured per station and run
+	// LOG-2: line logs prints the latest log
+	It("captures station agent output and prints the latest log [LOG-1, LOG-2]", func() {
+		agentScript := writeMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+
+		out := lineOK(dir, "logs", "review")
+		Expect(out).To(ContainSubstring("mock-agent ran with prompt"))
+		Expect(out).To(ContainSubstring("Review code"))
+	})
+
+	// LOG-1: Processes the agent leaves running don't hold up the station
+	It("does not wait for background processes left by the agent [LOG-1]", func() {
+		agentScript := writeMockAgentScript(dir, "forking-agent.sh", `#!/bin/bash
+sleep 20 &
+echo "agent done" >> agent-output.txt
+echo "agent done"
+`)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+
+		start := time.Now()
+		lineOK(dir, "run")
+		Expect(time.Since(start)).To(BeNumerically("<", 10*time.Second))
+		Expect(readHistoryJSON(dir)[0].Stations[0].Outcome).To(Equal("succeeded"))
+		Expect(lineOK(dir, "logs", "review")).To(ContainSubstring("agent done"))
+	})
+
+	// LOG-1: A failed station's log records why it failed
+	It("records the failure reason in a failed station's log [LOG-1]", func() {
+		failingAgent := writeFailingMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+failingAgent+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+
+		out := lineOK(dir, "logs", "review")
+		Expect(out).To(ContainSubstring("failing-agent ran with prompt"))
+		Expect(out).To(ContainSubstring("agent exited with error"))
+	})
+
+	// LOG-3: Older runs can be listed and selected
+	It("lists runs and selects an older run's log [LOG-3]", func() {
+		agentScript := writeMockAgentScript(dir, "counting-agent.sh", `#!/bin/bash
+echo "run at $(git rev-parse --short HEAD)"
+`)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "first change")
+		firstRef := shortRef(dir)
+
+		writeFile(dir, "extra.go", "package main\n")
+		gitCommit(dir, "second change")
+		secondRef := shortRef(dir)
+
+		runs := strings.Split(lineOK(dir, "logs", "review", "--list"), "\n")
+		Expect(runs).To(HaveLen(2))
+
+		Expect(lineOK(dir, "logs", "review")).To(ContainSubstring(secondRef))
+		Expect(lineOK(dir, "logs", "review", "--run", runs[0])).To(ContainSubstring(firstRef))
+
+		// Only runs with logs can be selected, never other files
+		writeFile(dir, ".line/secret.log", "not a log\n")
+		for _, run := range []string{"../../secret", "nope"} {
+			out, err := line(dir, "logs", "review", "--run", run)
+			Expect(err).To(HaveOccurred())
+			Expect(out).To(ContainSubstring("no log for station review run " + run))
+			Expect(out).NotTo(ContainSubstring("not a log"))
+		}
+	})
+
+	// LOG-2: Unknown stations are rejected
+	It("fails for an unknown station [LOG-2]", func() {
+		writeDefaultConfig(dir)
+		out, err := line(dir, "logs", "nope")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("unknown station"))
+	})
+
+	// LOG-4: --follow streams output until the agent exits
+	It("follows output while the agent is running [LOG-4]", func() {
+		agentScript := writeMockAgentScript(dir, "chatty-agent.sh", `#!/bin/bash
+echo "starting work"
+sleep 2
+echo "finished work"
+`)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "code.go", "package main\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "add code")
+
+		cmd := exec.Command(binaryPath, "run")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		Expect(cmd.Start()).To(Succeed())
+		defer func() {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		}()
+
+		Eventually(func() bool {
+			return fileExists(dir, ".line/stations/review.pid")
+		}, 5*time.Second, 100*time.Millisecond).Should(BeTrue())
+
+		out := lineOK(dir, "logs", "review", "-f")
+		Expect(out).To(ContainSubstring("starting work"))
+		Expect(out).To(ContainSubstring("finished work"))
+	})
+
+	// LOG-4: --follow keeps going while the station waits to retry
+	It("follows output through retries [LOG-4]", func() {
+		agentScript := writeMockAgentScript(dir, "flaky-agent.sh", `#!/bin/bash
+echo "attempt output"
+exit 1
+`)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+  retry_backoff: 2s
+
+stations:
+  - name: review
+    prompt: "Review code"
+    retries: 1
+`)
+		writeFile(dir, "code.go", "package main\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "add code")
+
+		cmd := exec.Command(binaryPath, "run")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		Expect(cmd.Start()).To(Succeed())
+		defer func() {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		}()
+
+		// The first attempt has failed once the retry is announced
+		Eventually(func() string {
+			logs, _ := line(dir, "logs", "review")
+			return logs
+		}, 5*time.Second, 100*time.Millisecond).Should(ContainSubstring("retrying in"))
+
+		out := lineOK(dir, "logs", "review", "-f")
+		Expect(strings.Count(out, "attempt output")).To(Equal(2))
+	})
+})
@@ -0,0 +1,138 @@
+package e2e_test
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line retry", func() {
+	var dir string
+	var scratch string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		var err error
+		scratch, err = os.MkdirTemp("", "line-retry-*")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { os.RemoveAll(scratch) })
+	})
+
+	// writeRetryConfig configures a counting first station and a second
+	// station that fails until the "fixed" file exists in scratch.
+	writeRetryConfig := func() {
+		countingAgent := writeMockAgentScript(dir, "counting-agent.sh", `#!/bin/bash
+echo ran >> `+scratch+`/first-count
+echo "first was here" >> first-output.txt
+`)
+		fragileAgent := writeMockAgentScript(dir, "fragile-agent.sh", `#!/bin/bash
+if [ ! -f `+scratch+`/fixed ]; then echo "not fixed yet"; exit 1; fi
+echo "second was here" >> second-output.txt
+`)
+		writeConfig(dir, `settings:
+  watches: master
+
+stations:
+  - name: first
+    command: `+countingAgent+`
+    prompt: "First station"
+  - name: second
+    command: `+fragileAgent+`
+    prompt: "Second station"
+`)
+	}
+
+	// RTY-1: Retry resumes from the first failed station without re-running earlier ones
+	It("re-runs from the first failed station, reusing earlier branches [RTY-1]", func() {
+		writeRetryConfig()
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+
+		Expect(lineOK(dir, "status")).To(ContainSubstring("failed"))
+		Expect(readFile(scratch, "first-count")).To(Equal("ran\n"))
+
+		writeFile(scratch, "fixed", "yes\n")
+		lineOK(dir, "retry")
+
+		// The first station was not re-run
+		Expect(readFile(scratch, "first-count")).To(Equal("ran\n"))
+
+		// The second station built on the first station's branch
+		git(dir, "checkout", "line/stn/second")
+		Expect(fileExists(dir, "first-output.txt")).To(BeTrue())
+		Expect(fileExists(dir, "second-output.txt")).To(BeTrue())
+		git(dir, "checkout", "master")
+
+		out := lineOK(dir, "status")
+		Expect(out).NotTo(ContainSubstring("failed"))
+	})
+
+	// RTY-2: Retry from a named station; resume is an alias
+	It("re-runs from the named station via the resume alias [RTY-2]", func() {
+		writeRetryConfig()
+		writeFile(scratch, "fixed", "yes\n")
+		installHooksForTest(dir)
+
+		writeFile(dir, "code.go", "package main\n")
+		gitCommit(dir, "add code")
+		Expect(readFile(scratch, "first-count")).To(Equal("ran\n"))
+
+		lineOK(dir, "resume", "first")
+		Expect(readFile(scratch, "first-count")).To(Equal("ran\nran\n"))
+
+		runs := readHistoryJSON(dir, "-n", "1")
+		Expect(runs[0].Stations).To(HaveLen(2))
+		Expect(runs[0].Stations[0].Name).To(Equal("first"))
+	})
+
+	// RTY-1: Nothing to retry
+	It("fails when no station has failed [RTY-1]", func() {
+		writeDefaultConfig(dir)
+		out, err := line(dir, "retry")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("no failed station"))
+	})
+
+	// RTY-3: Honours the runner PID lock
+	It("refuses to start while another line run is in progress [RTY-3]", func() {
+		slowAgent := writeSlowMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+slowAgent+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		writeFile(dir, "code.go", "package main\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "add code")
+
+		cmd := exec.Command(binaryPath, "run")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		Expect(cmd.Start()).To(Succeed())
+		DeferCleanup(func() {
+			killBackground(dir, "review")
+			_ = cmd.Wait()
+		})
+
+		Eventually(func() bool {
+			return fileExists(dir, ".line/stations/review.pid")
+		}, 5*time.Second, 100*time.Millisecond).Should(BeTrue())
+
+		out, err := line(dir, "retry", "review")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("already in progress"))
+	})
+})
@@ -95,6 +95,315 @@ stations:
 		Expect(err).To(HaveOccurred())
 		Expect(out).To(ContainSubstring("no resolvable command"))
 	})
+
+	// CFG-STN-6: Timeouts must be valid duration strings
+	It("reports invalid timeout durations [VAL-1, CFG-STN-6]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+  timeout: forever
+
+stations:
+  - name: review
+    timeout: "-5m"
+    prompt: "Review code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("settings.timeout: invalid duration"))
+		Expect(out).To(ContainSubstring("stations[0].timeout: invalid duration"))
+	})
+
+	// CFG-STN-10: Prompt files must exist and not be combined with prompt
+	It("reports missing prompt files and prompt set twice [VAL-1, CFG-STN-10]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt_file: prompts/missing.md
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("stations[0]: prompt_file: open"))
+		Expect(out).To(ContainSubstring("prompts/missing.md"))
+
+		writeFile(dir, "review.md", "Review {{include \"missing.md\"}}\n")
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt_file: review.md
+`)
+		out, err = line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("stations[0]: include: open"))
+
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+    prompt_file: review.md
+`)
+		out, err = line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("stations[0]: set prompt or prompt_file, not both"))
+	})
+
+	// CFG-STN-13: prompt_via must be a known delivery method
+	It("reports unknown prompt_via values [VAL-1, CFG-STN-13]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+  prompt_via: pipe
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt_via: carrier-pigeon
+    prompt: "Review code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`agent.prompt_via: "pipe" is not one of arg, stdin, file`))
+		Expect(out).To(ContainSubstring(`stations[0].prompt_via: "carrier-pigeon" is not one of arg, stdin, file`))
+	})
+
+	// RUN-29: output must name a known parser
+	It("reports unknown output parsers [VAL-1, RUN-29]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+  output: json
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    output: claude-json
+    prompt: "Review code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`agent.output: "json" is not one of text, claude-stream-json`))
+		Expect(out).To(ContainSubstring(`stations[0].output: "claude-json" is not one of text, claude-stream-json`))
+	})
+
+	// RUN-30: Budgets must not be negative
+	It("reports negative budgets [VAL-1, RUN-30]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+  budget:
+    run: {usd: -1}
+    day: {tokens: -5}
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("settings.budget.run: must not be negative"))
+		Expect(out).To(ContainSubstring("settings.budget.day: must not be negative"))
+	})
+
+	// RUN-33: path_policy must be a known policy
+	It("reports unknown path policies [VAL-1, RUN-33]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    allowed_paths: ["*.md"]
+    path_policy: ignore
+    prompt: "Review code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`stations[0].path_policy: "ignore" is not one of fail, revert`))
+	})
+
+	// RUN-34: Limits must not be negative
+	It("reports negative change limits [VAL-1, RUN-34]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    limits:
+      lines_added: -1
+    prompt: "Review code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("stations[0].limits.lines_added: must not be negative"))
+	})
+
+	// RUN-35: Secret rules and allow-lists must be valid
+	It("reports invalid secret scanner settings [VAL-1, RUN-35]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+  secrets:
+    allow: ["(unclosed"]
+    rules:
+      - pattern: "tok_[a-z]+"
+      - name: broken
+        pattern: "[z-a]"
+
+stations:
+  - name: review
+    prompt: "Review code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("settings.secrets.rules[0].name: required field is empty"))
+		Expect(out).To(ContainSubstring("settings.secrets.rules[1].pattern: error parsing regexp"))
+		Expect(out).To(ContainSubstring("settings.secrets.allow[0]: error parsing regexp"))
+	})
+
+	// RUN-36: Commit message templates must parse and only use known variables
+	It("reports commit message template errors [VAL-1, RUN-36]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+  commit_message: "{{.Station"
+
+stations:
+  - name: review
+    commit_message: "{{.Station}}: {{.Prompt}}"
+    prompt: "Review code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("settings.commit_message: template: prompt:1:"))
+		Expect(out).To(ContainSubstring("stations[0].commit_message: unknown variable .Prompt (available: .Station, .Agent, .Commit, .Summary, .Body)"))
+	})
+
+	// CFG-STN-14: Stations must reference defined agent profiles
+	It("reports unknown agent profiles [VAL-1, CFG-STN-14]", func() {
+		writeConfig(dir, `agents:
+  fast:
+    command: claude
+    timeout: soon
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    agent: fats
+    prompt: "Review code"
+  - name: cleanup
+    agent: fast
+    prompt: "Clean up code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`stations[0].agent: unknown agent profile "fats" (defined in agents: fast)`))
+		Expect(out).To(ContainSubstring("agents.fast.timeout: invalid duration"))
+		Expect(out).NotTo(ContainSubstring("stations[1]"))
+	})
+
+	// RUN-28: Fallbacks must name profiles; unavailable patterns must compile
+	It("reports unknown fallback profiles and invalid unavailable patterns [VAL-1, RUN-28]", func() {
+		writeConfig(dir, `agent:
+  command: claude
+  unavailable_patterns: ["rate (limit"]
+
+agents:
+  fast:
+    command: claude
+    fallback: [slow]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    fallback: [fast, nope]
+    prompt: "Review code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`agents.fast.fallback[0]: unknown agent profile "slow"`))
+		Expect(out).To(ContainSubstring(`stations[0].fallback[1]: unknown agent profile "nope"`))
+		Expect(out).To(ContainSubstring("agent.unavailable_patterns[0]: error parsing regexp"))
+	})
+
+	// VAL-2: Prompt templates must parse and only use known variables
+	It("reports prompt template errors and unknown variables [VAL-2, RUN-25]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review {{.Commit"
+  - name: cleanup
+    prompt: "Clean up {{.Comit}} by {{.Author}}"
+  - name: docs
+    prompt: "{{range .ChangedFiles}}{{.}} for {{$.Station}} {{$.Nope}}{{end}}"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("stations[0].prompt: template: prompt:1:"))
+		Expect(out).To(ContainSubstring("stations[1].prompt: unknown variable .Comit (available: .Commit,"))
+		Expect(out).To(ContainSubstring("stations[2].prompt: unknown variable .Nope"))
+	})
+
+	// CFG-STN-8: Needs must name stations declared earlier
+	It("reports needs on unknown or later stations [VAL-1, CFG-STN-8]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    needs: [cleanup]
+    prompt: "Review code"
+  - name: cleanup
+    needs: [review, nope]
+    prompt: "Clean up code"
+`)
+		out, err := line(dir, "validate")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`stations[0].needs[0]: "cleanup" is not a station declared before "review"`))
+		Expect(out).To(ContainSubstring(`stations[1].needs[1]: "nope" is not a station declared before "cleanup"`))
+		Expect(out).NotTo(ContainSubstring("stations[1].needs[0]"))
+	})
 })
 
 var _ = Describe("line explain", func() {
@@ -2,6 +2,7 @@ package e2e_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -301,6 +302,118 @@ stations:
 		_ = cmd.Wait()
 	})
 
+	// STAT-10: Machine-readable JSON status
+	It("emits machine-readable status with --json [STAT-10]", func() {
+		agentScript := writeMockAgent(dir)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+  args: ["-p"]
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "Review code"
+  - name: cleanup
+    prompt: "Clean up code"
+`)
+		writeFile(dir, "code.go", "package main\n")
+		git(dir, "add", ".")
+		git(dir, "commit", "-m", "add code")
+
+		lineOK(dir, "run")
+		writeFile(dir, "dirty.txt", "uncommitted change\n")
+
+		out := lineOK(dir, "status", "--json")
+		Expect(out).NotTo(ContainSubstring("\033["))
+
+		var report struct {
+			Config string `json:"config"`
+			Runner struct {
+				Active bool `json:"active"`
+			} `json:"runner"`
+			Watched struct {
+				Branch string `json:"branch"`
+				Ref    string `json:"ref"`
+				Dirty  bool   `json:"dirty"`
+			} `json:"watched"`
+			Stations []struct {
+				Name         string `json:"name"`
+				Branch       string `json:"branch"`
+				Ref          string `json:"ref"`
+				State        string `json:"state"`
+				CommitsAhead int    `json:"commits_ahead"`
+			} `json:"stations"`
+		}
+		Expect(json.Unmarshal([]byte(out), &report)).To(Succeed(), out)
+
+		Expect(report.Config).To(Equal("line.yaml"))
+		Expect(report.Runner.Active).To(BeFalse())
+		Expect(report.Watched.Branch).To(Equal("master"))
+		Expect(report.Watched.Ref).To(Equal(shortRef(dir)))
+		Expect(report.Watched.Dirty).To(BeTrue())
+
+		Expect(report.Stations).To(HaveLen(2))
+		Expect(report.Stations[0].Name).To(Equal("review"))
+		Expect(report.Stations[0].Branch).To(Equal("line/stn/review"))
+		Expect(report.Stations[0].Ref).To(Equal(git(dir, "rev-parse", "--short", "line/stn/review")))
+		Expect(report.Stations[0].State).To(Equal("up to date"))
+		Expect(report.Stations[0].CommitsAhead).To(Equal(1))
+		Expect(report.Stations[1].CommitsAhead).To(Equal(2))
+	})
+
+	// STAT-10: Go template formatting
+	It("renders status through a Go template with --format [STAT-10]", func() {
+		out := lineOK(dir, "status", "--format", "{{.Watched.Branch}}:{{range .Stations}}{{.Name}}={{.State}}{{end}}")
+		Expect(out).To(Equal("master:review=pending"))
+
+		out, err := line(dir, "status", "--json", "--format", "{{.Watched.Branch}}")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("--json cannot be combined with --format"))
+	})
+
+	// STAT-11: Dependency graph
+	It("shows what each station needs when it isn't the station above it [STAT-11, RUN-23]", func() {
+		writeConfig(dir, `agent:
+  command: echo
+
+settings:
+  watches: master
+
+stations:
+  - name: lint
+    prompt: "lint"
+  - name: docs
+    needs: []
+    prompt: "docs"
+  - name: final
+    needs: [lint, docs]
+    prompt: "final"
+  - name: polish
+    prompt: "polish"
+`)
+		out := lineOK(dir, "status")
+		Expect(out).To(MatchRegexp(`lint\s+-\s+\[pending\]\S*(\n|$)`))
+		Expect(out).To(ContainSubstring("[pending] ← master"))
+		Expect(out).To(ContainSubstring("[pending] ← lint, docs"))
+		Expect(out).To(MatchRegexp(`polish\s+-\s+\[pending\]\S*(\n|$)`))
+
+		var report struct {
+			Stations []struct {
+				Name  string   `json:"name"`
+				Needs []string `json:"needs"`
+			} `json:"stations"`
+		}
+		jsonOut := lineOK(dir, "status", "--json")
+		Expect(json.Unmarshal([]byte(jsonOut), &report)).To(Succeed(), jsonOut)
+		Expect(report.Stations).To(HaveLen(4))
+		Expect(report.Stations[0].Needs).To(BeEmpty())
+		Expect(report.Stations[1].Needs).To(BeEmpty())
+		Expect(report.Stations[2].Needs).To(Equal([]string{"lint", "docs"}))
+		Expect(report.Stations[3].Needs).To(Equal([]string{"final"}))
+	})
+
 	// STAT-9: After /line-rebase, all stations should show "up to date"
 	// because their work is already contained in the watched branch.
 	It("shows all stations as up to date after line-rebase picks up terminal station [STAT-9]", func() {
@@ -0,0 +1,94 @@
+package e2e_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("line trace", func() {
+	var dir, trigger string
+
+	BeforeEach(func() {
+		dir = tempRepo()
+		// Appends the last line of its prompt (the station's own prompt)
+		agentScript := writeMockAgentScript(dir, "notes-agent.sh", `#!/bin/bash
+echo "${@: -1}" | tail -n 1 >> notes.txt
+`)
+		writeConfig(dir, `agent:
+  command: `+agentScript+`
+
+settings:
+  watches: master
+
+stations:
+  - name: review
+    prompt: "reviewed"
+  - name: cleanup
+    prompt: "cleaned up"
+`)
+		writeFile(dir, "notes.txt", "notes\n")
+		gitCommit(dir, "add notes")
+		trigger = git(dir, "rev-parse", "HEAD")
+		lineOK(dir, "run")
+	})
+
+	// TRC-1: Station commits carry provenance trailers
+	It("adds provenance trailers to station commits [TRC-1]", func() {
+		runs := readHistoryJSON(dir)
+		trailer := func(rev, key string) string {
+			return git(dir, "log", "-1", "--format=%(trailers:key="+key+",valueonly)", rev)
+		}
+		Expect(trailer("line/stn/cleanup", "Line-Station")).To(Equal("cleanup"))
+		Expect(trailer("line/stn/cleanup", "Line-Source-Commit")).To(Equal(trigger))
+		Expect(trailer("line/stn/cleanup", "Line-Run-Id")).To(Equal(runs[0].ID))
+		Expect(trailer("line/stn/cleanup", "Line-Agent")).To(HaveSuffix("notes-agent.sh"))
+		Expect(trailer("line/stn/review", "Line-Station")).To(Equal("review"))
+		Expect(git(dir, "log", "-1", "--format=%s", "line/stn/review")).To(Equal("assembly-line: station review [skip line]"))
+	})
+
+	// TRC-2: line trace follows the trailers back to the human commit
+	It("traces station commits back to the human commit that triggered them [TRC-2]", func() {
+		runs := readHistoryJSON(dir)
+		out := lineOK(dir, "trace", "line/stn/cleanup")
+		lines := strings.Split(out, "\n")
+		Expect(lines).To(HaveLen(4))
+		Expect(lines[0]).To(MatchRegexp(`^[0-9a-f]{7}  station cleanup \(run ` + runs[0].ID + `, agent .*notes-agent\.sh\)$`))
+		Expect(lines[1]).To(Equal("         assembly-line: station cleanup [skip line]"))
+		Expect(lines[2]).To(Equal(trigger[:7] + "  Test <test@test.com>"))
+		Expect(lines[3]).To(Equal("         add notes"))
+
+		// A human commit traces to itself
+		Expect(lineOK(dir, "trace", trigger)).To(Equal(trigger[:7] + "  Test <test@test.com>\n         add notes"))
+
+		// A source that no longer exists is reported
+		git(dir, "commit", "--allow-empty", "-m", "orphan [skip line]\n\nLine-Station: ghost\nLine-Source-Commit: 0123456789abcdef0123456789abcdef01234567")
+		out = lineOK(dir, "trace", "HEAD")
+		Expect(out).To(ContainSubstring("station ghost"))
+		Expect(out).To(HaveSuffix("0123456  source commit not found"))
+
+		out, err := line(dir, "trace", "no-such-commit")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring(`unknown commit "no-such-commit"`))
+	})
+
+	// TRC-3: line blame shows the station and human commit behind each line
+	It("blames each line on its station and human commit [TRC-3]", func() {
+		review := git(dir, "rev-parse", "--short=7", "line/stn/review")
+		cleanup := git(dir, "rev-parse", "--short=7", "line/stn/cleanup")
+
+		// Pick up the station changes as /line-rebase would
+		git(dir, "rebase", "line/stn/cleanup")
+		out := lineOK(dir, "blame", "notes.txt")
+		Expect(strings.Split(out, "\n")).To(Equal([]string{
+			trigger[:7] + " -       " + trigger[:7] + "    1) notes",
+			review + " review  " + trigger[:7] + "    2) reviewed",
+			cleanup + " cleanup " + trigger[:7] + "    3) cleaned up",
+		}))
+
+		out = lineOK(dir, "blame", "--rev", "line/stn/review", "notes.txt")
+		Expect(out).To(ContainSubstring("review " + trigger[:7] + "    2) reviewed"))
+		Expect(out).NotTo(ContainSubstring("cleaned up"))
+	})
+})
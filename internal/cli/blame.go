@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/provenance"
+	"github.com/spf13/cobra"
+)
+
+var blameRevFlag string
+
+var blameCmd = &cobra.Command{
+	Use:   "blame <file>",
+	Short: "Show which station and human commit last changed each line of a file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		lines, err := git.Blame(".", blameRevFlag, args[0])
+		if err != nil {
+			return err
+		}
+
+		// TRC-3: Each line's commit, the station that made it and the human
+		// commit behind it; commits are traced once
+		type blame struct {
+			station, origin string
+		}
+		traced := make(map[string]blame)
+		width := len("-")
+		for _, l := range lines {
+			if _, ok := traced[l.Commit]; ok {
+				continue
+			}
+			b := blame{station: "-", origin: "?"}
+			if chain, found, err := provenance.Trace(".", l.Commit); err == nil {
+				if chain[0].IsStation() {
+					b.station = chain[0].Station
+				}
+				if found {
+					b.origin = shortSHA(chain[len(chain)-1].Commit)
+				}
+			}
+			traced[l.Commit] = b
+			width = max(width, len(b.station))
+		}
+
+		for _, l := range lines {
+			b := traced[l.Commit]
+			fmt.Fprintf(os.Stdout, "%s %-*s %-7s %4d) %s\n", shortSHA(l.Commit), width, b.station, b.origin, l.Line, l.Text)
+		}
+		return nil
+	},
+}
+
+func init() {
+	blameCmd.Flags().StringVar(&blameRevFlag, "rev", "HEAD", "blame the file as of this commit or branch, e.g. line/stn/<station>")
+	rootCmd.AddCommand(blameCmd)
+}
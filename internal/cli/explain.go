@@ -32,9 +32,24 @@ COMMANDS
               from .gitignore. Safe to run even when line was never initialized
               (no-op).
   run         Execute the station pipeline (called by the post-commit hook).
-              Stations run in sequence, each in an ephemeral Git worktree.
+              Stations run in sequence, or concurrently where needs allows,
+              each in an ephemeral Git worktree.
+              --station <name> (repeatable) runs only the named stations;
+              --from/--to <name> bound the range. Selected stations rebase
+              onto their predecessor's existing branch. --force bypasses the
+              skip-marker and .lineignore checks and the stations' paths
+              filters. --dry-run makes the
+              trigger decisions and prints each station's command, args,
+              full prompt (with preamble), worktree and branch without
+              running anything.
+  retry       Re-run the line from the first failed station (clearing its
+              failure marker), or from the named station: line retry
+              [station]. Earlier stations are not re-run; their branches
+              are reused as predecessors. Refuses to start while another
+              line run is in progress. Alias: resume.
   gate        Run all gates (called by the pre-commit hook). Non-zero exit
-              from any gate blocks the commit.
+              from any gate blocks the commit. With settings.secrets.gate,
+              staged changes are then scanned for secrets.
   status      Show station status. Header: ⏸ (grey) for inactive or ▶ (green)
               for active, followed by the config file name. Output includes
               headings. Stations listed starting with the watched branch; each
@@ -42,15 +57,47 @@ COMMANDS
               per-station symbols: ✓ up-to-date — the only commits between
               the station and the watched branch HEAD are skip-marker commits
               (green); ● agent running (orange, with uptime duration);
-              ○ pending (yellow); ✗ failed (red). Use -f to refresh every
-              2 seconds, flicker-free with a hidden cursor. Status is
-              computed on-demand, not cached.
+              ○ pending (yellow); ✗ failed (red); ⏱ timed out (red);
+              $ budget exceeded (red).
+              Use -f to refresh every 2 seconds, flicker-free with a
+              hidden cursor. Status is computed on-demand, not cached.
+              --json emits machine-readable status: runner {active, pid},
+              watched {branch, ref, dirty}, stations [{name, branch, ref,
+              state, agent_pid, uptime_seconds, commits_ahead, usage}],
+              today {cost_usd, tokens}, budget_exceeded.
+              --format <template> renders the same data via a Go template
+              using Go field names, e.g.
+              '{{range .Stations}}{{.Name}}={{.State}} {{end}}'.
+  logs        Print the latest agent output log for a station:
+              line logs <station>. Each station run's combined stdout and
+              stderr is captured under .line/logs/<station>/<run>.log (20
+              most recent kept). --list lists runs with logs; --run <id>
+              selects an older run; -f follows output while the station runs.
+  history     List recent line runs, newest first: run ID, triggering
+              commit, start time and duration, then per station the
+              outcome, exit code, duration and resulting commit. Read from
+              the append-only ledger .line/history.jsonl. A run stopped
+              by a newer one is recorded by the next run, marked
+              interrupted. Filter with --station <name> and
+              --outcome <outcome>; -n limits the count (default 20);
+              --json for machine-readable output.
+  trace       Show where a commit came from: line trace <commit>. Station
+              commits carry the git trailers Line-Station,
+              Line-Source-Commit (the triggering watched-branch commit),
+              Line-Run-Id and Line-Agent; trace follows them back to the
+              human commit, printing the station, run and agent, then the
+              human commit's author, each with its subject.
+  blame       line blame <file>: each line with the commit that last
+              changed it, the station that made it (- for human commits)
+              and the human commit behind it. --rev <commit> blames
+              another commit or branch, e.g. line/stn/<station>.
   statusline  One-line status for Claude Code's statusline integration.
               Uses ▶/⏸ symbols matching line status. Prompts to run
               /line-rebase when terminal station has unmerged commits.
               No external dependencies.
   schema      Output the YAML configuration schema to stdout.
-  validate    Validate line.yaml and print specific errors, or "valid".
+  validate    Validate line.yaml and print specific errors, or "valid",
+              including prompt template errors.
   explain     Print this reference (what you are reading now).
 
   Skill: /line-rebase
@@ -71,9 +118,41 @@ CONFIG FORMAT (line.yaml)
   agent:
     command: claude                              # default agent executable
     args: ["--dangerously-skip-permissions", "-p"]  # default agent arguments
+    prompt_file: prompts/common.md               # prepended to every station prompt (or prompt:)
+    prompt_via: arg                              # arg (default), stdin or file
+
+  agents:                                        # named agent profiles (optional)
+    haiku:
+      command: claude
+      args: ["--dangerously-skip-permissions", "--model", "haiku", "-p"]
+      env: {ANTHROPIC_LOG: debug}                # extra environment for the agent
+      prompt_via: arg
+      timeout: 10m
+      unavailable_exit_codes: [75]               # exit codes meaning "unavailable", not failed
+      unavailable_patterns: ["rate limit"]       # stderr regexps meaning "unavailable"
+      fallback: [aider]                          # profiles tried in order when unavailable
+      output: claude-stream-json                 # parse cost/tokens (args need --output-format stream-json)
+    aider:
+      command: aider
+      args: ["--yes", "--message", "{{prompt}}"]
 
   settings:
     watches: main                                # Git branch to watch (required)
+    timeout: 15m                                 # default station time limit (optional)
+    retries: 2                                   # default retries of a failed station (optional)
+    retry_backoff: 30s                           # delay before first retry, doubles (default 10s)
+    preamble: "Do not commit. Follow CONTRIBUTING.md."  # replaces the built-in preamble (optional)
+    quarantine: true                             # keep rejected changes on refs/line/quarantine/<station>
+    gate_stations: true                          # run gates on station changes before committing
+    commit_message: "{{.Station}}: {{.Summary}}" # station commit message template (optional)
+    secrets:                                     # built-in secret scanner (on for stations)
+      allow: ["EXAMPLE", "^testdata/"]           # regexps for secrets or file paths to ignore
+      rules: [{name: internal token, pattern: "itk_[a-z0-9]{12}"}]  # extra rules (optional entropy)
+      gate: true                                 # also scan staged changes in line gate
+    budget:                                      # spending limits (optional)
+      station: {usd: 1.00}                       # per station run, including retries
+      run: {usd: 5.00, tokens: 2000000}          # per line run
+      day: {usd: 20.00}                          # all runs started today
 
   gates:
     - name: lint                                 # gate name (required)
@@ -82,24 +161,146 @@ CONFIG FORMAT (line.yaml)
   stations:
     - name: review                               # unique name → branch line/stn/review
       prompt: "Review the code for issues."      # prompt text (required)
+    - name: lint-fix
+      agent: haiku                               # use the haiku profile
+      prompt_file: prompts/lint.md               # prompt from a file, relative to line.yaml
+    - name: aider
+      command: aider
+      args: ["--message-file", "{{prompt_file}}", "--yes"]  # placeholder instead of appending
     - name: test
       command: custom-agent                      # overrides agent.command
       args: ["--flag", "-p"]                     # overrides agent.args
+      timeout: 30m                               # overrides settings.timeout ("0" = none)
+      retries: 0                                 # overrides settings.retries
+      preamble: ""                               # overrides settings.preamble; "" disables it
+      verify: ["go test ./..."]                  # must pass before the changes are committed
+      gate: false                                # overrides settings.gate_stations
+      commit_message: "test: {{.Summary}}"       # overrides settings.commit_message
       prompt: "Run all tests, fix failures."
+    - name: docs
+      needs: []                                  # builds on the watched branch, in parallel with review
+      paths: ["*.go", "README.md"]               # only run when matching files changed (gitignore syntax)
+      paths_ignore: ["*_test.go"]                # changed files that don't count
+      allowed_paths: ["*.md", "docs/"]           # files the agent may change (gitignore syntax)
+      forbidden_paths: ["CHANGELOG.md"]          # files the agent must not change
+      path_policy: revert                        # revert out-of-scope files, or fail (default)
+      limits:                                    # reject changes larger than these (optional)
+        files_changed: 20
+        lines_added: 400
+        lines_removed: 800
+        deleted_files: 2
+        keep_files: ["*_test.go"]                # files that must not be deleted
+      prompt: "Update the docs."
+    - name: final
+      needs: [test, docs]                        # builds on both once they succeed
+      prompt: "Check everything fits together."
 
 CONFIG SEMANTICS
   - settings.watches is required. All other top-level keys are optional.
   - Each station needs a resolvable command: either station.command or
     agent.command must be set. station.command takes priority.
   - Station args follow the same inheritance: station.args overrides agent.args.
-  - The prompt is appended as the final argument to the resolved command+args.
+  - station.agent selects a profile from agents. Resolution order for
+    command, args, prompt_via and timeout: station, then its profile, then
+    agent (settings for timeout). The profile's env is added to the
+    agent's environment. Unknown profile names fail validation.
+  - station.fallback (or its profile's fallback) lists profiles tried in
+    order when the agent is unavailable: not installed, or failing with
+    an unavailable_exit_codes code or stderr matching unavailable_patterns
+    (also settable on agent). Other failures are genuine. line history
+    records the agent used; line status and line history show
+    "via <agent>" for fallbacks.
+  - output: claude-stream-json (agent, profile or station) parses the
+    agent's Claude Code stream-json output: total cost, token usage,
+    turns, result text and error subtype are recorded per station run in
+    line history. line status shows each station's last cost and tokens
+    and a "Today:" total. The default, text, is not parsed.
+  - settings.budget limits spending per station run, per line run and per
+    day, in usd and/or tokens, counting usage parsed from agent output.
+    Once a limit is reached no further stations start: they are marked
+    "$ budget exceeded" in line status (which also prints the reason) and
+    line statusline. A station over its station budget is not retried.
+    line retry resumes from the first station that did not start.
+  - verify commands run in order (sh -c) in the station's worktree after
+    its agent succeeds; output goes to the station log. If one fails the
+    attempt fails and is retried as usual; then the changes are discarded
+    and the station is marked failed. With quarantine (settings default,
+    station override) they are kept on refs/line/quarantine/<station>.
+    Files verify commands or gates leave behind are held to the path
+    scope and limits like the agent's changes.
+  - allowed_paths / forbidden_paths (gitignore syntax) are checked
+    against the changed files before verify and gates, and again after
+    them on the tree to be committed. Files outside them are logged and
+    shown in line status ("out of scope:").
+    path_policy: revert restores them and commits the rest; fail (the
+    default) rejects the changes like a failing verify command.
+  - limits bound the agent's changes (files_changed, lines_added,
+    lines_removed, deleted_files; keep_files must not be deleted). They
+    are checked after the path scope, before verify and gates and again
+    after them; exceeding one rejects the changes like a failing verify
+    command.
+  - settings.gate_stations (station override: gate) runs the gates in
+    the station's worktree after verify and before committing; a failing
+    gate rejects the changes like a failing verify command.
+  - The secret scanner checks lines a station adds for credentials
+    (known formats, and high-entropy key/secret/token/password values)
+    as the last check before committing; findings (file:line: rule,
+    secret redacted) reject the changes. settings.secrets.stations: false
+    disables it; settings.secrets.gate also runs it in line gate.
+  - Agents describe their changes by writing a commit message (summary
+    line, blank line, body) to $LINE_COMMIT_MSG_FILE (.line/commit-msg in
+    the worktree, never committed), or by ending their output with
+    "Summary: <text>". commit_message (settings, station override) is a
+    Go template for the station commit message with .Station, .Agent,
+    .Commit, .Summary and .Body; the default is "assembly-line: station
+    <name>: <summary>" plus the body. [skip line] is always added to the
+    subject if missing. line history shows the summaries.
+  - The prompt is appended as the final argument to the resolved command+args,
+    unless prompt_via (agent default, station override) is stdin (piped to
+    standard input) or file (written to a temp file whose path is
+    appended). {{prompt}} / {{prompt_file}} anywhere in args are replaced
+    by the prompt / the temp file's path, and then nothing is appended.
+  - Prompts are Go templates rendered just before the agent starts, with
+    the triggering commit (tip of settings.watches): {{.Commit}},
+    {{.Message}}, {{.Author}}, {{.ChangedFiles}} (list), {{.Diffstat}},
+    {{.Diff}} (full diff), {{.Predecessor}} (branch built on) and
+    {{.Station}}. line validate reports template errors and unknown
+    variables.
+  - prompt_file loads a prompt from a file relative to line.yaml (use
+    prompt or prompt_file, not both). {{include "path"}} in a prompt or
+    prompt file inserts a shared fragment, relative to the including file;
+    includes may nest. agent.prompt / agent.prompt_file is prepended to
+    every station's prompt.
   - Station names must be unique — each maps to a Git branch (line/stn/<name>).
   - Gates run in order; any failure blocks the commit.
   - Stations run in order; a failed station blocks subsequent stations.
+  - needs lists the stations a station builds on (each must be declared
+    earlier). Without needs a station needs the one before it; needs: []
+    builds on the watched branch. Stations whose needs have succeeded run
+    concurrently; a station needing several rebases onto their combined
+    changes and fails if they conflict. line status annotates stations
+    that don't build on the one listed above them (e.g. "← test, docs").
+  - paths/paths_ignore filter the files changed by the triggering commit.
+    A station none of whose changed files match is skipped: its agent
+    doesn't run, its branch is rebased onto its predecessor, and line
+    status shows "⊘ skipped". Skipped stations count as succeeded for
+    stations that need them.
+  - timeout is a Go duration string (e.g. "90s", "15m", "1h").
+    station.timeout overrides settings.timeout; "0" disables it. Without a
+    timeout, agents run unbounded. On timeout the agent's process group gets
+    SIGTERM, then SIGKILL after a 10s grace period, and the station is
+    marked "timed out" (a failure that blocks subsequent stations).
+  - A failed attempt (non-zero exit or timeout) is retried up to retries
+    times (station.retries overrides settings.retries), waiting
+    retry_backoff before the first retry and doubling it each time, up
+    to 10 minutes. Each retry resets the worktree to its pre-agent state.
+    line status shows the attempt count (e.g. "attempt 2/3").
 
 CONSTRAINTS
   - line prepends a preamble prompt to each station's configured prompt
     instructing the agent not to commit — line handles committing itself.
+    settings.preamble replaces it; station.preamble overrides that, and
+    an empty preamble ("") disables it.
   - Each station operates only on its own branch (line/stn/<name>); stations
     must not operate on any other branches.
   - Stations must not re-trigger line run.
@@ -2,9 +2,11 @@ package cli
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/re-cinq/assembly-line/internal/config"
 	"github.com/re-cinq/assembly-line/internal/gate"
+	"github.com/re-cinq/assembly-line/internal/runner"
 	"github.com/spf13/cobra"
 )
 
@@ -26,6 +28,14 @@ var gateCmd = &cobra.Command{
 			return fmt.Errorf("gates failed: %w", err)
 		}
 
+		// RUN-35: The built-in secret scanner runs as a final gate
+		if cfg.Settings.Secrets.Gate {
+			fmt.Fprintln(os.Stderr, "gate: running secrets")
+			if err := runner.ScanStaged(".", cfg); err != nil {
+				return fmt.Errorf("gates failed: %w", err)
+			}
+		}
+
 		return nil
 	},
 }
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyStationFlag string
+	historyOutcomeFlag string
+	historyJSONFlag    bool
+	historyLimitFlag   int
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List recent line runs and what each station did",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if historyOutcomeFlag != "" && !slices.Contains(state.Outcomes, historyOutcomeFlag) {
+			return fmt.Errorf("unknown outcome %q (want one of: %s)", historyOutcomeFlag, strings.Join(state.Outcomes, ", "))
+		}
+
+		runs, err := state.ReadHistory(".")
+		if err != nil {
+			return fmt.Errorf("reading history: %w", err)
+		}
+
+		// HIST-2: Newest first, filtered and limited
+		filtered := make([]state.Run, 0, len(runs))
+		for i := len(runs) - 1; i >= 0; i-- {
+			if historyLimitFlag > 0 && len(filtered) >= historyLimitFlag {
+				break
+			}
+			if run, ok := filterRun(runs[i], historyStationFlag, historyOutcomeFlag); ok {
+				filtered = append(filtered, run)
+			}
+		}
+
+		// HIST-3: Machine-readable output
+		if historyJSONFlag {
+			out, err := json.MarshalIndent(filtered, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		// RUN-28: Fallbacks are told apart from each station's primary agent;
+		// history is readable without a config, just without them
+		primary := make(map[string]string)
+		if cfg, err := config.Load(configPath); err == nil {
+			for _, station := range cfg.Stations {
+				primary[station.Name] = cfg.ResolveStation(station).Agent
+			}
+		}
+
+		for _, run := range filtered {
+			printRun(run, primary)
+		}
+		return nil
+	},
+}
+
+// filterRun returns run with only the station records matching the given
+// station name and outcome (either may be empty to match all). ok is false
+// when a filter is set and no station records match.
+func filterRun(run state.Run, station, outcome string) (state.Run, bool) {
+	if station == "" && outcome == "" {
+		return run, true
+	}
+	var stations []state.StationRun
+	for _, s := range run.Stations {
+		if station != "" && s.Name != station {
+			continue
+		}
+		if outcome != "" && s.Outcome != outcome {
+			continue
+		}
+		stations = append(stations, s)
+	}
+	run.Stations = stations
+	return run, len(stations) > 0
+}
+
+// printRun prints a run and its station records in human-readable form.
+// primary maps station names to their primary agents, so that stations a
+// fallback agent ran are shown with it.
+func printRun(run state.Run, primary map[string]string) {
+	// HIST-4: Runs stopped before they finished are marked
+	mark := ""
+	if run.Interrupted {
+		mark = "  " + colorOrange + "interrupted" + colorReset
+	}
+	fmt.Fprintf(os.Stdout, "%s  %s  %s  %s%s\n",
+		run.ID, shortSHA(run.Commit), run.Start.Local().Format("2006-01-02 15:04:05"), run.End.Sub(run.Start).Round(time.Second), mark)
+	for _, s := range run.Stations {
+		symbol, color := "✓", colorGreen
+		switch s.Outcome {
+		case state.OutcomeTimedOut:
+			symbol, color = "⏱", colorRed
+		case state.OutcomeFailed:
+			symbol, color = "✗", colorRed
+		case state.OutcomeSkipped:
+			symbol, color = "⊘", colorGrey
+		case state.OutcomeInterrupted:
+			symbol, color = "■", colorOrange
+		}
+		d := time.Duration(s.DurationMS) * time.Millisecond
+		extra := ""
+		if s.Attempts > 1 {
+			extra = fmt.Sprintf("  (%d attempts)", s.Attempts)
+		}
+		if p, ok := primary[s.Name]; ok && s.Agent != "" && s.Agent != p {
+			extra += "  via " + s.Agent
+		}
+		if s.Usage != nil {
+			extra += "  " + formatUsage(s.Usage.CostUSD, s.Usage.Tokens())
+		}
+		fmt.Fprintf(os.Stdout, "%s  %s %-17s%-11sexit %-4d%-9s%s%s%s\n",
+			color, symbol, s.Name, s.Outcome, s.ExitCode, d.Round(time.Second), shortSHA(s.Commit), extra, colorReset)
+		if s.Summary != "" {
+			fmt.Fprintf(os.Stdout, "      %s\n", s.Summary)
+		}
+	}
+}
+
+// shortSHA abbreviates a full commit SHA for display.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historyStationFlag, "station", "", "only show records for this station")
+	historyCmd.Flags().StringVar(&historyOutcomeFlag, "outcome", "", "only show station records with this outcome (succeeded, failed, \"timed out\", skipped or interrupted)")
+	historyCmd.Flags().BoolVar(&historyJSONFlag, "json", false, "output runs as JSON")
+	historyCmd.Flags().IntVarP(&historyLimitFlag, "limit", "n", 20, "maximum number of runs to show (0 for all)")
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsFollowFlag bool
+	logsListFlag   bool
+	logsRunFlag    string
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs <station>",
+	Short: "Show agent output logs for a station",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+
+		name := args[0]
+		if !hasStation(cfg, name) {
+			return fmt.Errorf("unknown station %q", name)
+		}
+
+		runIDs, err := state.ListStationLogs(".", name)
+		if err != nil {
+			return fmt.Errorf("listing logs: %w", err)
+		}
+
+		// LOG-3: List the runs that have logs, oldest first
+		if logsListFlag {
+			for _, id := range runIDs {
+				fmt.Println(id)
+			}
+			return nil
+		}
+
+		if len(runIDs) == 0 {
+			return fmt.Errorf("no logs for station %s", name)
+		}
+
+		// LOG-2: Default to the latest run; LOG-3: --run selects an older one
+		// Only runs that have logs are accepted, so --run can't name a file
+		// outside the station's logs
+		runID := runIDs[len(runIDs)-1]
+		if logsRunFlag != "" {
+			if !slices.Contains(runIDs, logsRunFlag) {
+				return fmt.Errorf("no log for station %s run %s", name, logsRunFlag)
+			}
+			runID = logsRunFlag
+		}
+
+		f, err := os.Open(state.StationLogPath(".", name, runID))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("no log for station %s run %s", name, runID)
+			}
+			return err
+		}
+		defer f.Close()
+
+		if logsFollowFlag {
+			return followLog(".", name, f)
+		}
+		_, err = io.Copy(os.Stdout, f)
+		return err
+	},
+}
+
+// hasStation reports whether cfg defines a station with the given name.
+func hasStation(cfg *config.Config, name string) bool {
+	for _, s := range cfg.Stations {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// followLog copies f to stdout, polling for new output while the station is
+// running, including between retries (LOG-4). Returns once the station has
+// finished and the log has been drained.
+func followLog(dir, stationName string, f *os.File) error {
+	for {
+		if _, err := io.Copy(os.Stdout, f); err != nil {
+			return err
+		}
+		// Between attempts no agent runs, but the station still does
+		pid, _, _ := state.ReadStationPID(dir, stationName)
+		agentRunning := pid > 0 && state.IsProcessRunning(pid)
+		if !agentRunning && !state.IsStationRunning(dir, stationName) {
+			_, err := io.Copy(os.Stdout, f)
+			return err
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsFollowFlag, "follow", "f", false, "keep printing output while the agent is running")
+	logsCmd.Flags().BoolVarP(&logsListFlag, "list", "l", false, "list the runs that have logs, oldest first")
+	logsCmd.Flags().StringVarP(&logsRunFlag, "run", "r", "", "show the log for the given run instead of the latest")
+	rootCmd.AddCommand(logsCmd)
+}
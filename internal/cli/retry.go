@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+var retryCmd = &cobra.Command{
+	Use:     "retry [station]",
+	Aliases: []string{"resume"},
+	Short:   "Re-run the line from the named station, or the first failed station",
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+
+		from := ""
+		if len(args) == 1 {
+			from = args[0]
+		}
+		return runner.Retry(".", cfg, from)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(retryCmd)
+}
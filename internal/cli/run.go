@@ -6,6 +6,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var runOpts runner.Options
+
 var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Run the assembly line pipeline (post-commit)",
@@ -15,10 +17,15 @@ var runCmd = &cobra.Command{
 			return err
 		}
 
-		return runner.Run(".", cfg)
+		return runner.Run(".", cfg, runOpts)
 	},
 }
 
 func init() {
+	runCmd.Flags().StringArrayVar(&runOpts.Stations, "station", nil, "run only this station (repeatable)")
+	runCmd.Flags().StringVar(&runOpts.From, "from", "", "first station to run")
+	runCmd.Flags().StringVar(&runOpts.To, "to", "", "last station to run")
+	runCmd.Flags().BoolVar(&runOpts.Force, "force", false, "run even if the commit has a skip marker, only touches .lineignore'd files or matches no station paths")
+	runCmd.Flags().BoolVar(&runOpts.DryRun, "dry-run", false, "print what each station would run without running it")
 	rootCmd.AddCommand(runCmd)
 }
@@ -1,10 +1,13 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/re-cinq/assembly-line/internal/config"
@@ -24,7 +27,11 @@ const (
 	colorGrey   = "\033[90m"
 )
 
-var followFlag bool
+var (
+	followFlag       bool
+	statusJSONFlag   bool
+	statusFormatFlag string
+)
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
@@ -35,6 +42,17 @@ var statusCmd = &cobra.Command{
 			return err
 		}
 
+		// STAT-10: Machine-readable output
+		if statusJSONFlag || statusFormatFlag != "" {
+			if followFlag {
+				return fmt.Errorf("--follow cannot be combined with --json or --format")
+			}
+			if statusJSONFlag && statusFormatFlag != "" {
+				return fmt.Errorf("--json cannot be combined with --format")
+			}
+			return printStatusMachine(".", cfg, statusFormatFlag)
+		}
+
 		if followFlag {
 			// Hide cursor and clear screen during follow mode; restore on exit or signal
 			fmt.Print("\033[?25l\033[2J")
@@ -79,33 +97,53 @@ type stationInfo struct {
 	symbol    string
 	color     string
 	name      string    // "pending", "agent running", "failed", "up to date"
+	agentPID  int       // non-zero when agent is running
 	startTime time.Time // non-zero when agent is running
+
+	// RUN-18: current/last attempt and maximum attempts, for running and
+	// failed stations
+	attempt     int
+	maxAttempts int
 }
 
 // computeStationInfo returns the display state for a station based on process
 // and git state (STAT-5: on-demand computation).
 func computeStationInfo(dir string, station config.Station, watchedFullRef, watchedBranch string) stationInfo {
+	// RUN-30: Not started because the budget was exhausted
+	if reason, _ := state.ReadStationFailure(dir, station.Name); reason == state.FailReasonBudget {
+		return stationInfo{symbol: "$", color: colorRed, name: "budget exceeded"}
+	}
 	branchName := git.StationBranchName(station.Name)
 	if !git.BranchExists(dir, branchName) {
 		return stationInfo{symbol: "○", color: colorYellow, name: "pending"}
 	}
 
+	attempt, maxAttempts := state.ReadStationAttempt(dir, station.Name)
 	agentPID, startTime, _ := state.ReadStationPID(dir, station.Name)
 	if agentPID > 0 && state.IsProcessRunning(agentPID) {
-		return stationInfo{symbol: "●", color: colorOrange, name: "agent running", startTime: startTime}
-	}
-	if state.ReadStationFailed(dir, station.Name) {
-		return stationInfo{symbol: "✗", color: colorRed, name: "failed"}
+		return stationInfo{symbol: "●", color: colorOrange, name: "agent running", agentPID: agentPID, startTime: startTime, attempt: attempt, maxAttempts: maxAttempts}
 	}
-	if watchedFullRef != "" && git.IsAncestor(dir, watchedFullRef, branchName) {
-		return stationInfo{symbol: "✓", color: colorGreen, name: "up to date"}
+	if reason, failed := state.ReadStationFailure(dir, station.Name); failed {
+		// RUN-17: Timed-out stations are failed, but shown distinctly
+		if reason == state.FailReasonTimedOut {
+			return stationInfo{symbol: "⏱", color: colorRed, name: "timed out", attempt: attempt, maxAttempts: maxAttempts}
+		}
+		return stationInfo{symbol: "✗", color: colorRed, name: "failed", attempt: attempt, maxAttempts: maxAttempts}
 	}
+	upToDate := watchedFullRef != "" && git.IsAncestor(dir, watchedFullRef, branchName)
 	// STAT-8: If the only commits between station and watched branch are
 	// skip-marker commits, the station is still up to date.
-	if watchedFullRef != "" && git.OnlySkipCommitsBetween(dir, branchName, watchedBranch, runner.SkipMarkers) {
-		return stationInfo{symbol: "✓", color: colorGreen, name: "up to date"}
+	if !upToDate && watchedFullRef != "" {
+		upToDate = git.OnlySkipCommitsBetween(dir, branchName, watchedBranch, runner.SkipMarkers)
 	}
-	return stationInfo{symbol: "○", color: colorYellow, name: "pending"}
+	if !upToDate {
+		return stationInfo{symbol: "○", color: colorYellow, name: "pending"}
+	}
+	// RUN-24: Up to date, but without running its agent on the latest commit
+	if state.IsStationSkipped(dir, station.Name) {
+		return stationInfo{symbol: "⊘", color: colorGrey, name: "skipped"}
+	}
+	return stationInfo{symbol: "✓", color: colorGreen, name: "up to date"}
 }
 
 // formatUptime formats the duration since startTime as a human-readable string.
@@ -125,6 +163,191 @@ func formatUptime(startTime time.Time) string {
 	return fmt.Sprintf("%dh %dm", h, m)
 }
 
+// statusReport is the machine-readable form of line status (STAT-10).
+type statusReport struct {
+	Config   string          `json:"config"`
+	Runner   runnerStatus    `json:"runner"`
+	Watched  watchedStatus   `json:"watched"`
+	Stations []stationStatus `json:"stations"`
+
+	// RUN-29: Agent usage recorded by today's runs
+	Today *dayUsage `json:"today,omitempty"`
+
+	// RUN-30: Why the last run stopped starting stations
+	BudgetExceeded string `json:"budget_exceeded,omitempty"`
+}
+
+// dayUsage totals the agent usage of a day's runs.
+type dayUsage struct {
+	CostUSD float64 `json:"cost_usd"`
+	Tokens  int64   `json:"tokens"`
+}
+
+// runnerStatus describes the line runner process.
+type runnerStatus struct {
+	Active bool `json:"active"`
+	PID    int  `json:"pid,omitempty"`
+}
+
+// watchedStatus describes the watched branch.
+type watchedStatus struct {
+	Branch string `json:"branch"`
+	Ref    string `json:"ref"`
+	Dirty  bool   `json:"dirty"`
+}
+
+// stationStatus describes a single station.
+type stationStatus struct {
+	Name          string `json:"name"`
+	Branch        string `json:"branch"`
+	Ref           string `json:"ref,omitempty"`
+	State         string `json:"state"`
+	AgentPID      int    `json:"agent_pid,omitempty"`
+	UptimeSeconds int64  `json:"uptime_seconds,omitempty"`
+	CommitsAhead  int    `json:"commits_ahead"`
+	Attempt       int    `json:"attempt,omitempty"`
+	MaxAttempts   int    `json:"max_attempts,omitempty"`
+
+	// RUN-23: Stations this one builds on; empty means the watched branch
+	Needs []string `json:"needs"`
+
+	// RUN-28: The agent the station last ran, and whether it was a fallback
+	Agent    string `json:"agent,omitempty"`
+	Fallback bool   `json:"fallback,omitempty"`
+
+	// RUN-33: Files the agent last changed outside its allowed paths
+	OutOfScope []string `json:"out_of_scope,omitempty"`
+
+	// RUN-29: Agent usage of the station's last recorded run
+	Usage *state.Usage `json:"usage,omitempty"`
+
+	info stationInfo
+}
+
+// buildStatusReport computes the current status of the line on-demand (STAT-5).
+func buildStatusReport(dir string, cfg *config.Config) statusReport {
+	report := statusReport{Config: filepath.Base(configPath)}
+
+	// STAT-3: Line runner state
+	pid, _ := state.ReadPID(dir)
+	if pid > 0 && state.IsProcessRunning(pid) {
+		report.Runner = runnerStatus{Active: true, PID: pid}
+	}
+
+	// STAT-1: Watched branch ref and dirty flag
+	watchedRef, _ := git.HeadShortRef(dir)
+	watchedDirty, _ := git.IsDirty(dir)
+	report.Watched = watchedStatus{Branch: cfg.Settings.Watches, Ref: watchedRef, Dirty: watchedDirty}
+
+	// Get the watched branch full ref for ancestor checks (STAT-5: on-demand)
+	watchedFullRef, _ := git.Run(dir, "rev-parse", cfg.Settings.Watches)
+
+	// RUN-29: Usage from the run ledger
+	lastUsage, today := historyUsage(dir, time.Now())
+	report.Today = today
+	report.BudgetExceeded, _ = state.ReadBudgetExceeded(dir)
+
+	report.Stations = make([]stationStatus, 0, len(cfg.Stations))
+	for _, station := range cfg.Stations {
+		branchName := git.StationBranchName(station.Name)
+		info := computeStationInfo(dir, station, watchedFullRef, cfg.Settings.Watches)
+		st := stationStatus{
+			Name:        station.Name,
+			Branch:      branchName,
+			State:       info.name,
+			AgentPID:    info.agentPID,
+			Attempt:     info.attempt,
+			MaxAttempts: info.maxAttempts,
+			Needs:       cfg.Needs(station),
+			Agent:       state.ReadStationAgent(dir, station.Name),
+			Usage:       lastUsage[station.Name],
+			OutOfScope:  state.ReadStationOutOfScope(dir, station.Name),
+			info:        info,
+		}
+		st.Fallback = st.Agent != "" && st.Agent != cfg.ResolveStation(station).Agent
+		if !info.startTime.IsZero() {
+			st.UptimeSeconds = int64(time.Since(info.startTime).Seconds())
+		}
+		if git.BranchExists(dir, branchName) {
+			if branchRef, err := git.Run(dir, "rev-parse", "--short", branchName); err == nil {
+				st.Ref = branchRef
+			}
+			st.CommitsAhead, _ = git.CountCommitsBetween(dir, cfg.Settings.Watches, branchName)
+		}
+		report.Stations = append(report.Stations, st)
+	}
+
+	return report
+}
+
+// historyUsage returns each station's usage in its latest recorded run that
+// reported usage, and the total usage of runs started on now's day (nil when
+// none reported usage).
+func historyUsage(dir string, now time.Time) (map[string]*state.Usage, *dayUsage) {
+	runs, _ := state.ReadHistory(dir)
+	last := make(map[string]*state.Usage)
+	var today *dayUsage
+	year, month, day := now.Date()
+	for _, run := range runs {
+		y, m, d := run.Start.In(now.Location()).Date()
+		for _, s := range run.Stations {
+			if s.Usage == nil {
+				continue
+			}
+			last[s.Name] = s.Usage
+			if y == year && m == month && d == day {
+				if today == nil {
+					today = &dayUsage{}
+				}
+				today.CostUSD += s.Usage.CostUSD
+				today.Tokens += s.Usage.Tokens()
+			}
+		}
+	}
+	return last, today
+}
+
+// formatUsage formats a cost and token count, e.g. "$0.42, 35.2k tokens".
+func formatUsage(costUSD float64, tokens int64) string {
+	return fmt.Sprintf("$%.2f, %s tokens", costUSD, formatTokens(tokens))
+}
+
+// formatTokens abbreviates a token count, e.g. "950", "35.2k" or "1.3M".
+func formatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// printStatusMachine prints the status report as JSON, or through the given
+// Go template when format is non-empty (STAT-10).
+func printStatusMachine(dir string, cfg *config.Config, format string) error {
+	report := buildStatusReport(dir, cfg)
+	if format == "" {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	tmpl, err := template.New("status").Parse(format)
+	if err != nil {
+		return fmt.Errorf("parsing --format template: %w", err)
+	}
+	if err := tmpl.Execute(os.Stdout, report); err != nil {
+		return fmt.Errorf("executing --format template: %w", err)
+	}
+	fmt.Println()
+	return nil
+}
+
 func printStatus(dir string, cfg *config.Config, clearEOL bool) error {
 	// When clearEOL is true (follow mode), append ANSI erase-to-end-of-line
 	// after each line to prevent stale characters from shorter redraws.
@@ -133,13 +356,13 @@ func printStatus(dir string, cfg *config.Config, clearEOL bool) error {
 		eol = "\033[K\n"
 	}
 
+	report := buildStatusReport(dir, cfg)
+
 	// STAT-3: Line runner indicator at the top
-	pid, _ := state.ReadPID(dir)
-	configName := filepath.Base(configPath)
-	if pid > 0 && state.IsProcessRunning(pid) {
-		fmt.Fprintf(os.Stdout, "%s▶%s %s%s", colorGreen, colorReset, configName, eol)
+	if report.Runner.Active {
+		fmt.Fprintf(os.Stdout, "%s▶%s %s%s", colorGreen, colorReset, report.Config, eol)
 	} else {
-		fmt.Fprintf(os.Stdout, "%s⏸%s %s%s", colorGrey, colorReset, configName, eol)
+		fmt.Fprintf(os.Stdout, "%s⏸%s %s%s", colorGrey, colorReset, report.Config, eol)
 	}
 
 	// Blank line + column headers
@@ -147,42 +370,87 @@ func printStatus(dir string, cfg *config.Config, clearEOL bool) error {
 	fmt.Fprintf(os.Stdout, "%-21s%-9s%s%s", "Stations", "Head", "Status", eol)
 
 	// Print watched branch
-	watchedRef, _ := git.HeadShortRef(dir)
-	watchedDirty, _ := git.IsDirty(dir)
 	dirtyStr := ""
-	if watchedDirty {
+	if report.Watched.Dirty {
 		dirtyStr = "(dirty)"
 	}
-	fmt.Fprintf(os.Stdout, "%-21s%-9s%s%s", cfg.Settings.Watches, watchedRef, dirtyStr, eol)
-
-	// Get the watched branch full ref for ancestor checks (STAT-5: on-demand)
-	watchedFullRef, _ := git.Run(dir, "rev-parse", cfg.Settings.Watches)
+	fmt.Fprintf(os.Stdout, "%-21s%-9s%s%s", report.Watched.Branch, report.Watched.Ref, dirtyStr, eol)
 
 	// Print each station
-	for _, station := range cfg.Stations {
-		branchName := git.StationBranchName(station.Name)
-		ref := "-"
-
-		if git.BranchExists(dir, branchName) {
-			if branchRef, err := git.Run(dir, "rev-parse", "--short", branchName); err == nil {
-				ref = branchRef
-			}
+	for i, st := range report.Stations {
+		ref := st.Ref
+		if ref == "" {
+			ref = "-"
 		}
 
-		info := computeStationInfo(dir, station, watchedFullRef, cfg.Settings.Watches)
-		extra := ""
-		if !info.startTime.IsZero() {
+		var details []string
+		if !st.info.startTime.IsZero() {
 			// STAT-7: Show uptime duration instead of PID/start time
-			extra = fmt.Sprintf(" (%s)", formatUptime(info.startTime))
+			details = append(details, formatUptime(st.info.startTime))
+		}
+		if st.Fallback {
+			// RUN-28: Show when a fallback agent did the work
+			details = append(details, "via "+st.Agent)
+		}
+		if st.Usage != nil && st.info.startTime.IsZero() {
+			// RUN-29: What the station's last run cost
+			details = append(details, formatUsage(st.Usage.CostUSD, st.Usage.Tokens()))
+		}
+		if len(st.OutOfScope) > 0 {
+			// RUN-33: Show what the agent changed that it shouldn't have
+			details = append(details, "out of scope: "+strings.Join(st.OutOfScope, " "))
 		}
+		if st.MaxAttempts > 1 {
+			// RUN-18: Show attempt counts when retries are configured
+			details = append(details, fmt.Sprintf("attempt %d/%d", st.Attempt, st.MaxAttempts))
+		}
+		extra := ""
+		if len(details) > 0 {
+			extra = fmt.Sprintf(" (%s)", strings.Join(details, ", "))
+		}
+		// STAT-11: Show what a station builds on when it isn't simply the
+		// station listed above it
+		if !buildsOnPrevious(report, i) {
+			needs := st.Needs
+			if len(needs) == 0 {
+				needs = []string{report.Watched.Branch}
+			}
+			extra += " ← " + strings.Join(needs, ", ")
+		}
+
+		fmt.Fprintf(os.Stdout, "%s  %s %-17s%-9s[%s]%s%s%s", st.info.color, st.info.symbol, st.Name, ref, st.State, extra, colorReset, eol)
+	}
 
-		fmt.Fprintf(os.Stdout, "%s  %s %-17s%-9s[%s]%s%s%s", info.color, info.symbol, station.Name, ref, info.name, extra, colorReset, eol)
+	if report.Today != nil || report.BudgetExceeded != "" {
+		fmt.Fprintf(os.Stdout, "%s", eol)
+	}
+
+	// RUN-29: Today's total agent spend
+	if report.Today != nil {
+		fmt.Fprintf(os.Stdout, "Today: %s%s", formatUsage(report.Today.CostUSD, report.Today.Tokens), eol)
+	}
+
+	// RUN-30: The line stopped because its budget was exhausted
+	if report.BudgetExceeded != "" {
+		fmt.Fprintf(os.Stdout, "%sBudget exceeded: %s%s%s", colorRed, report.BudgetExceeded, colorReset, eol)
 	}
 
 	return nil
 }
 
+// buildsOnPrevious reports whether the i'th station builds on exactly the
+// station listed before it (or, for the first, on the watched branch).
+func buildsOnPrevious(report statusReport, i int) bool {
+	needs := report.Stations[i].Needs
+	if i == 0 {
+		return len(needs) == 0
+	}
+	return len(needs) == 1 && needs[0] == report.Stations[i-1].Name
+}
+
 func init() {
 	statusCmd.Flags().BoolVarP(&followFlag, "follow", "f", false, "refresh every 2 seconds")
+	statusCmd.Flags().BoolVar(&statusJSONFlag, "json", false, "output status as JSON")
+	statusCmd.Flags().StringVar(&statusFormatFlag, "format", "", "format status using a Go template")
 	rootCmd.AddCommand(statusCmd)
 }
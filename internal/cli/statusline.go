@@ -50,6 +50,11 @@ func buildStatusLine(dir string, cfg *config.Config) (string, error) {
 
 	result := fmt.Sprintf("%s %s", lineSymbol, strings.Join(parts, " "))
 
+	// RUN-30: The last run stopped because its budget was exhausted
+	if _, exceeded := state.ReadBudgetExceeded(dir); exceeded {
+		result += " | " + colorRed + "budget exceeded" + colorReset
+	}
+
 	// SL-2: Check if terminal station has commits not in the watched branch
 	if len(cfg.Stations) > 0 {
 		terminalStation := cfg.Stations[len(cfg.Stations)-1]
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/re-cinq/assembly-line/internal/provenance"
+	"github.com/spf13/cobra"
+)
+
+var traceCmd = &cobra.Command{
+	Use:   "trace <commit>",
+	Short: "Show which station and human commit produced a commit",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// TRC-2: Follow the provenance trailers back to a human commit
+		chain, found, err := provenance.Trace(".", args[0])
+		if err != nil {
+			return err
+		}
+		for _, o := range chain {
+			printOrigin(o)
+		}
+		if !found {
+			fmt.Fprintf(os.Stdout, "%s  source commit not found\n", shortSHA(chain[len(chain)-1].Source))
+		}
+		return nil
+	},
+}
+
+// printOrigin prints a commit and what produced it: the station, run and
+// agent for a station commit, or the author for a human one.
+func printOrigin(o provenance.Origin) {
+	if o.IsStation() {
+		fmt.Fprintf(os.Stdout, "%s  station %s (run %s, agent %s)\n", shortSHA(o.Commit), o.Station, o.RunID, o.Agent)
+	} else {
+		fmt.Fprintf(os.Stdout, "%s  %s\n", shortSHA(o.Commit), o.Author)
+	}
+	fmt.Fprintf(os.Stdout, "         %s\n", o.Subject)
+}
+
+func init() {
+	rootCmd.AddCommand(traceCmd)
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -10,6 +13,45 @@ import (
 type Agent struct {
 	Command string   `yaml:"command"`
 	Args    []string `yaml:"args"`
+
+	// Prompt is shared instructions prepended to every station's prompt.
+	// PromptFile loads it from a file instead (CFG-STN-11).
+	Prompt     string `yaml:"prompt,omitempty"`
+	PromptFile string `yaml:"prompt_file,omitempty"`
+
+	// PromptVia is how the prompt reaches the command: one of PromptVias
+	// (RUN-27). Defaults to PromptViaArg.
+	PromptVia string `yaml:"prompt_via,omitempty"`
+
+	// Output names the parser for the command's output (RUN-29).
+	Output string `yaml:"output,omitempty"`
+
+	Unavailable `yaml:",inline"`
+}
+
+// Unavailable describes agent failures that mean the agent is unavailable
+// (e.g. rate-limited) rather than that it failed, so the station falls back
+// to its next agent (RUN-28). Patterns are regular expressions matched
+// against the agent's stderr.
+type Unavailable struct {
+	UnavailableExitCodes []int    `yaml:"unavailable_exit_codes,omitempty"`
+	UnavailablePatterns  []string `yaml:"unavailable_patterns,omitempty"`
+}
+
+// AgentProfile is a named agent configuration that stations can select with
+// agent: <name> (CFG-STN-14).
+type AgentProfile struct {
+	Command   string            `yaml:"command,omitempty"`
+	Args      []string          `yaml:"args,omitempty"`
+	Env       map[string]string `yaml:"env,omitempty"`
+	PromptVia string            `yaml:"prompt_via,omitempty"`
+	Timeout   string            `yaml:"timeout,omitempty"`
+	Output    string            `yaml:"output,omitempty"`
+
+	// Fallback lists the profiles tried in order when this one is
+	// unavailable (RUN-28).
+	Fallback    []string `yaml:"fallback,omitempty"`
+	Unavailable `yaml:",inline"`
 }
 
 type Gate struct {
@@ -19,20 +61,192 @@ type Gate struct {
 
 type Station struct {
 	Name    string   `yaml:"name"`
+	Agent   string   `yaml:"agent,omitempty"` // name of an agents profile
 	Command string   `yaml:"command,omitempty"`
 	Args    []string `yaml:"args,omitempty"`
 	Prompt  string   `yaml:"prompt"`
+	Timeout string   `yaml:"timeout,omitempty"`
+	Retries *int     `yaml:"retries,omitempty"`
+	Needs   []string `yaml:"needs,omitempty"`
+
+	// Preamble overrides settings.preamble for this station; an empty
+	// string disables it (RUN-26).
+	Preamble *string `yaml:"preamble,omitempty"`
+
+	// PromptVia overrides agent.prompt_via for this station (RUN-27).
+	PromptVia string `yaml:"prompt_via,omitempty"`
+
+	// Fallback overrides the agent profile's fallback list (RUN-28).
+	Fallback []string `yaml:"fallback,omitempty"`
+
+	// Output overrides the agent's output parser (RUN-29).
+	Output string `yaml:"output,omitempty"`
+
+	// PromptFile loads the prompt from a file, relative to the config file
+	// (CFG-STN-10). Load reads it into Prompt.
+	PromptFile string `yaml:"prompt_file,omitempty"`
+
+	// Paths and PathsIgnore filter which commits run the station, using
+	// gitignore syntax (RUN-24).
+	Paths       []string `yaml:"paths,omitempty"`
+	PathsIgnore []string `yaml:"paths_ignore,omitempty"`
+
+	// Verify lists shell commands that must pass in the worktree before
+	// the agent's changes are committed (RUN-31).
+	Verify []string `yaml:"verify,omitempty"`
+
+	// Quarantine overrides settings.quarantine (RUN-31).
+	Quarantine *bool `yaml:"quarantine,omitempty"`
+
+	// Gate overrides settings.gate_stations (RUN-32).
+	Gate *bool `yaml:"gate,omitempty"`
+
+	// AllowedPaths and ForbiddenPaths limit which files the agent may
+	// change, using gitignore syntax; PathPolicy says what happens to other
+	// changes (RUN-33).
+	AllowedPaths   []string `yaml:"allowed_paths,omitempty"`
+	ForbiddenPaths []string `yaml:"forbidden_paths,omitempty"`
+	PathPolicy     string   `yaml:"path_policy,omitempty"`
+
+	// Limits bound the size of the agent's changes (RUN-34).
+	Limits Limits `yaml:"limits,omitempty"`
+
+	// CommitMessage overrides settings.commit_message (RUN-36).
+	CommitMessage string `yaml:"commit_message,omitempty"`
+}
+
+// Limits bound the size of a station's changes (RUN-34). Unset limits don't
+// apply.
+type Limits struct {
+	FilesChanged *int `yaml:"files_changed,omitempty"`
+	LinesAdded   *int `yaml:"lines_added,omitempty"`
+	LinesRemoved *int `yaml:"lines_removed,omitempty"`
+	DeletedFiles *int `yaml:"deleted_files,omitempty"`
+
+	// KeepFiles are gitignore-syntax patterns for files that must not be
+	// deleted, e.g. "*_test.go".
+	KeepFiles []string `yaml:"keep_files,omitempty"`
 }
 
 type Settings struct {
-	Watches string `yaml:"watches"`
+	Watches      string `yaml:"watches"`
+	Timeout      string `yaml:"timeout,omitempty"`
+	Retries      int    `yaml:"retries,omitempty"`
+	RetryBackoff string `yaml:"retry_backoff,omitempty"`
+
+	// Preamble replaces DefaultPreamble for all stations; an empty string
+	// disables it (RUN-26).
+	Preamble *string `yaml:"preamble,omitempty"`
+
+	// Budget limits what agents may spend before the line stops (RUN-30).
+	Budget Budget `yaml:"budget,omitempty"`
+
+	// Quarantine keeps rejected changes on a quarantine ref instead of
+	// discarding them (RUN-31).
+	Quarantine bool `yaml:"quarantine,omitempty"`
+
+	// GateStations runs the gates against each station's changes before
+	// they are committed (RUN-32).
+	GateStations bool `yaml:"gate_stations,omitempty"`
+
+	// Secrets configures the built-in secret scanner (RUN-35).
+	Secrets Secrets `yaml:"secrets,omitempty"`
+
+	// CommitMessage is the template for station commit messages, replacing
+	// DefaultCommitMessage (RUN-36).
+	CommitMessage string `yaml:"commit_message,omitempty"`
+}
+
+// Secrets configures the built-in secret scanner (RUN-35).
+type Secrets struct {
+	// Stations scans each station's changes before they are committed;
+	// on unless set to false.
+	Stations *bool `yaml:"stations,omitempty"`
+
+	// Gate also scans staged changes when line gate runs.
+	Gate bool `yaml:"gate,omitempty"`
+
+	// Allow lists regular expressions for file paths or secrets to ignore.
+	Allow []string `yaml:"allow,omitempty"`
+
+	// Rules are added to the built-in rules.
+	Rules []SecretRule `yaml:"rules,omitempty"`
+}
+
+// SecretRule is a user-defined secret scanner rule.
+type SecretRule struct {
+	Name    string  `yaml:"name"`
+	Pattern string  `yaml:"pattern"`
+	Entropy float64 `yaml:"entropy,omitempty"` // minimum bits per character
+}
+
+// ScanStations reports whether station changes are scanned for secrets.
+func (s Secrets) ScanStations() bool {
+	return s.Stations == nil || *s.Stations
 }
 
+// Budget holds spending limits for a single station run, a whole line run
+// and all runs started on one day (RUN-30).
+type Budget struct {
+	Station Limit `yaml:"station,omitempty"`
+	Run     Limit `yaml:"run,omitempty"`
+	Day     Limit `yaml:"day,omitempty"`
+}
+
+// Limit is a spending limit in US dollars and/or tokens. Zero means no limit.
+type Limit struct {
+	USD    float64 `yaml:"usd,omitempty"`
+	Tokens int64   `yaml:"tokens,omitempty"`
+}
+
+// DefaultRetryBackoff is the delay before the first retry of a failed station
+// when settings.retry_backoff is not set. The delay doubles on each retry.
+const DefaultRetryBackoff = 10 * time.Second
+
+// DefaultPreamble is prepended to every station's prompt unless replaced or
+// disabled by settings.preamble or a station's preamble (RUN-12).
+const DefaultPreamble = "IMPORTANT: Do NOT commit any changes. Do NOT run git commit. Make file changes only. The system will handle committing. " +
+	"Write a one-line summary of your changes (then optionally a blank line and details) to $LINE_COMMIT_MSG_FILE."
+
+// DefaultCommitMessage is the template for station commit messages unless
+// replaced by settings.commit_message or a station's commit_message (RUN-36).
+// The skip marker is added to the subject line by the runner.
+const DefaultCommitMessage = "assembly-line: station {{.Station}}{{if .Summary}}: {{.Summary}}{{end}}{{if .Body}}\n\n{{.Body}}{{end}}"
+
+// Prompt delivery methods (RUN-27).
+const (
+	PromptViaArg   = "arg"   // final argument
+	PromptViaStdin = "stdin" // standard input
+	PromptViaFile  = "file"  // path to a temporary file, as the final argument
+)
+
+// PromptVias lists every prompt delivery method.
+var PromptVias = []string{PromptViaArg, PromptViaStdin, PromptViaFile}
+
+// Policies for changes outside a station's allowed paths (RUN-33).
+const (
+	PathPolicyFail   = "fail"   // fail the station
+	PathPolicyRevert = "revert" // revert the out-of-scope files
+)
+
+// PathPolicies lists every path policy.
+var PathPolicies = []string{PathPolicyFail, PathPolicyRevert}
+
+// Agent output parsers (RUN-29).
+const (
+	OutputText             = "text"               // not parsed
+	OutputClaudeStreamJSON = "claude-stream-json" // claude -p --output-format stream-json
+)
+
+// Outputs lists every agent output parser.
+var Outputs = []string{OutputText, OutputClaudeStreamJSON}
+
 type Config struct {
-	Agent    Agent     `yaml:"agent"`
-	Settings Settings  `yaml:"settings"`
-	Gates    []Gate    `yaml:"gates"`
-	Stations []Station `yaml:"stations"`
+	Agent    Agent                   `yaml:"agent"`
+	Agents   map[string]AgentProfile `yaml:"agents,omitempty"`
+	Settings Settings                `yaml:"settings"`
+	Gates    []Gate                  `yaml:"gates"`
+	Stations []Station               `yaml:"stations"`
 }
 
 // ResolvedStation holds the fully resolved command/args for a station.
@@ -41,6 +255,24 @@ type ResolvedStation struct {
 	Command string
 	Args    []string
 	Prompt  string
+	Timeout time.Duration // zero means no timeout
+
+	Preamble  string   // prepended to the prompt; empty when disabled
+	PromptVia string   // one of PromptVias
+	Env       []string // extra "KEY=value" environment for the agent, sorted
+
+	Agent       string // agent profile name, or the command without one
+	Output      string // one of Outputs
+	Unavailable        // failures that mean the agent is unavailable
+
+	Retries      int           // extra attempts after a failed one
+	RetryBackoff time.Duration // delay before the first retry; doubles each retry
+
+	Verify     []string // commands that must pass before committing
+	Quarantine bool     // keep rejected changes on a quarantine ref
+	Gate       bool     // run the gates before committing
+
+	CommitMessage string // commit message template
 }
 
 func Load(path string) (*Config, error) {
@@ -58,26 +290,158 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("config: settings.watches is required")
 	}
 
+	if err := cfg.loadPrompts(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
 	return &cfg, nil
 }
 
-// ResolveStation returns the fully resolved command and args for a station,
-// falling back to the top-level agent defaults.
+// ResolveStation returns the fully resolved command and args for a station:
+// station fields first, then the station's agent profile, then the top-level
+// agent defaults. The timeout, retries and preamble fall back to settings;
+// invalid durations (reported by Validate) resolve to no timeout and the
+// default retry backoff.
 func (c *Config) ResolveStation(s Station) ResolvedStation {
-	cmd := s.Command
-	if cmd == "" {
-		cmd = c.Agent.Command
-	}
+	profile := c.Agents[s.Agent]
+
+	cmd := firstNonEmpty(s.Command, profile.Command, c.Agent.Command)
 
 	args := s.Args
+	if args == nil {
+		args = profile.Args
+	}
 	if args == nil {
 		args = c.Agent.Args
 	}
 
+	d, _ := ParseDuration(firstNonEmpty(s.Timeout, profile.Timeout, c.Settings.Timeout))
+
+	retries := c.Settings.Retries
+	if s.Retries != nil {
+		retries = *s.Retries
+	}
+
+	backoff, err := ParseDuration(c.Settings.RetryBackoff)
+	if err != nil || c.Settings.RetryBackoff == "" {
+		backoff = DefaultRetryBackoff
+	}
+
+	preamble := DefaultPreamble
+	if s.Preamble != nil {
+		preamble = *s.Preamble
+	} else if c.Settings.Preamble != nil {
+		preamble = *c.Settings.Preamble
+	}
+
+	via := firstNonEmpty(s.PromptVia, profile.PromptVia, c.Agent.PromptVia, PromptViaArg)
+
+	quarantine := c.Settings.Quarantine
+	if s.Quarantine != nil {
+		quarantine = *s.Quarantine
+	}
+
+	gate := c.Settings.GateStations
+	if s.Gate != nil {
+		gate = *s.Gate
+	}
+
+	unavailable := c.Agent.Unavailable
+	if s.Agent != "" {
+		unavailable = profile.Unavailable
+	}
+
+	var env []string
+	for k, v := range profile.Env {
+		env = append(env, k+"="+v)
+	}
+	slices.Sort(env)
+
+	prompt := s.Prompt
+	if c.Agent.Prompt != "" {
+		prompt = c.Agent.Prompt + "\n\n" + prompt
+	}
+
 	return ResolvedStation{
-		Name:    s.Name,
-		Command: cmd,
-		Args:    args,
-		Prompt:  s.Prompt,
+		Name:         s.Name,
+		Command:      cmd,
+		Args:         args,
+		Prompt:       prompt,
+		Preamble:     preamble,
+		PromptVia:    via,
+		Env:          env,
+		Agent:        firstNonEmpty(s.Agent, cmd),
+		Output:       firstNonEmpty(s.Output, profile.Output, c.Agent.Output, OutputText),
+		Unavailable:  unavailable,
+		Timeout:      d,
+		Retries:      retries,
+		RetryBackoff: backoff,
+		Verify:       s.Verify,
+		Quarantine:   quarantine,
+		Gate:         gate,
+
+		CommitMessage: firstNonEmpty(s.CommitMessage, c.Settings.CommitMessage, DefaultCommitMessage),
+	}
+}
+
+// AgentChain returns the agents to try for a station, in order (RUN-28): the
+// station's resolved agent, then each fallback profile — the station's
+// fallback list, or else its profile's. Fallbacks use their profile's
+// command, args and prompt delivery rather than the station's overrides.
+func (c *Config) AgentChain(s Station) []ResolvedStation {
+	chain := []ResolvedStation{c.ResolveStation(s)}
+	fallback := s.Fallback
+	if fallback == nil {
+		fallback = c.Agents[s.Agent].Fallback
+	}
+	for _, name := range fallback {
+		fs := s
+		fs.Agent, fs.Command, fs.Args, fs.PromptVia, fs.Output = name, "", nil, "", ""
+		chain = append(chain, c.ResolveStation(fs))
+	}
+	return chain
+}
+
+// firstNonEmpty returns the first non-empty string, or "".
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Needs returns the names of the stations s depends on. Without an explicit
+// needs list a station depends on the station before it (the implicit chain);
+// an explicit empty list means it builds directly on the watched branch.
+func (c *Config) Needs(s Station) []string {
+	if s.Needs != nil {
+		return s.Needs
+	}
+	for i, st := range c.Stations {
+		if st.Name == s.Name {
+			if i == 0 {
+				return []string{}
+			}
+			return []string{c.Stations[i-1].Name}
+		}
+	}
+	return []string{}
+}
+
+// ParseDuration parses a Go duration string such as "30s" or "1h30m". An empty
+// string parses as zero. Negative durations are rejected.
+func ParseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q (use e.g. \"90s\", \"15m\", \"1h\")", s)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid duration %q: must not be negative", s)
 	}
+	return d, nil
 }
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// maxIncludeDepth bounds nested prompt includes, catching include cycles.
+const maxIncludeDepth = 10
+
+// includePattern matches an include directive: {{include "path"}}.
+var includePattern = regexp.MustCompile(`\{\{\s*include\s+"([^"]+)"\s*\}\}`)
+
+// loadPrompts reads prompt_file entries into the corresponding prompts and
+// expands includes, resolving paths relative to the config file's directory
+// (CFG-STN-10).
+func (c *Config) loadPrompts(dir string) error {
+	var err error
+	if c.Agent.Prompt, err = loadPrompt(dir, c.Agent.Prompt, c.Agent.PromptFile); err != nil {
+		return fmt.Errorf("agent: %w", err)
+	}
+	for i := range c.Stations {
+		s := &c.Stations[i]
+		if s.Prompt, err = loadPrompt(dir, s.Prompt, s.PromptFile); err != nil {
+			return fmt.Errorf("stations[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// loadPrompt returns the prompt text from an inline prompt or a prompt file
+// (at most one may be set), with includes expanded.
+func loadPrompt(dir, prompt, file string) (string, error) {
+	if file == "" {
+		return expandIncludes(dir, prompt, 0)
+	}
+	if prompt != "" {
+		return "", fmt.Errorf("set prompt or prompt_file, not both")
+	}
+	path := filepath.Join(dir, file)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("prompt_file: %w", err)
+	}
+	return expandIncludes(filepath.Dir(path), string(data), 0)
+}
+
+// expandIncludes replaces each include directive in text with the contents
+// of the named file, relative to dir. Included files may include others.
+func expandIncludes(dir, text string, depth int) (string, error) {
+	var err error
+	expanded := includePattern.ReplaceAllStringFunc(text, func(directive string) string {
+		if err != nil {
+			return ""
+		}
+		if depth >= maxIncludeDepth {
+			err = fmt.Errorf("include %s: includes nested more than %d deep (is there a cycle?)", directive, maxIncludeDepth)
+			return ""
+		}
+		path := filepath.Join(dir, includePattern.FindStringSubmatch(directive)[1])
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			err = fmt.Errorf("include: %w", readErr)
+			return ""
+		}
+		var content string
+		content, err = expandIncludes(filepath.Dir(path), string(data), depth+1)
+		return content
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
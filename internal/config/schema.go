@@ -2,6 +2,9 @@ package config
 
 import "encoding/json"
 
+// durationPattern matches Go duration strings such as "90s", "15m" or "1h30m".
+const durationPattern = `^(0|([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$`
+
 // Schema returns a JSON Schema describing line.yaml as indented JSON.
 func Schema() []byte {
 	schema := map[string]any{
@@ -23,11 +26,93 @@ func Schema() []byte {
 					},
 					"args": map[string]any{
 						"type":        "array",
-						"description": "Default arguments passed to the agent command. The station prompt is appended as the final argument. Overridden by station-level args.",
+						"description": "Default arguments passed to the agent command. The station prompt is appended as the final argument (see prompt_via). {{prompt}} and {{prompt_file}} anywhere in an argument are replaced by the prompt and the path of a file holding it, in which case nothing is appended. Overridden by station-level args.",
+						"items":       map[string]any{"type": "string"},
+					},
+					"prompt": map[string]any{
+						"type":        "string",
+						"description": "Shared instructions prepended to every station's prompt. Supports the same templating and includes as station prompts.",
+					},
+					"prompt_file": map[string]any{
+						"type":        "string",
+						"description": "Path to a file holding agent.prompt, relative to the config file. Mutually exclusive with agent.prompt.",
+					},
+					"prompt_via": map[string]any{
+						"type":        "string",
+						"enum":        PromptVias,
+						"description": "How the prompt reaches the command: \"arg\" appends it as the final argument (default), \"stdin\" writes it to standard input, \"file\" writes it to a temporary file and appends the file's path. Ignored for delivery as an argument when args contain {{prompt}} or {{prompt_file}}. Overridden by station-level prompt_via.",
+					},
+					"output": map[string]any{
+						"type":        "string",
+						"enum":        Outputs,
+						"description": "Parser for the agent's output: \"text\" (default) leaves it unparsed; \"claude-stream-json\" parses Claude Code's --output-format stream-json output and records cost, tokens, turns and the result per station run. Overridden by profile and station output.",
+					},
+					"unavailable_exit_codes": map[string]any{
+						"type":        "array",
+						"description": "Exit codes meaning the agent is unavailable (e.g. rate-limited) rather than that it failed; the station falls back to its next agent. A command that is not installed always counts as unavailable.",
+						"items":       map[string]any{"type": "integer"},
+					},
+					"unavailable_patterns": map[string]any{
+						"type":        "array",
+						"description": "Regular expressions matched against the agent's stderr when it fails; a match means the agent is unavailable and the station falls back to its next agent.",
 						"items":       map[string]any{"type": "string"},
 					},
 				},
 			},
+			"agents": map[string]any{
+				"description": "Named agent profiles. A station selects one with agent: <name>; its fields override agent defaults and are overridden by the station's own fields.",
+				"type":        "object",
+				"additionalProperties": map[string]any{
+					"type":                 "object",
+					"additionalProperties": false,
+					"properties": map[string]any{
+						"command": map[string]any{
+							"type":        "string",
+							"description": "Executable to run (e.g. \"claude\", \"aider\").",
+						},
+						"args": map[string]any{
+							"type":        "array",
+							"description": "Arguments passed to the command, with the same prompt handling as agent.args.",
+							"items":       map[string]any{"type": "string"},
+						},
+						"env": map[string]any{
+							"type":                 "object",
+							"description":          "Extra environment variables for the agent process.",
+							"additionalProperties": map[string]any{"type": "string"},
+						},
+						"prompt_via": map[string]any{
+							"type":        "string",
+							"enum":        PromptVias,
+							"description": "How the prompt reaches the command (\"arg\", \"stdin\" or \"file\").",
+						},
+						"timeout": map[string]any{
+							"type":        "string",
+							"pattern":     durationPattern,
+							"description": "Maximum run time for the agent as a Go duration string, overriding settings.timeout.",
+						},
+						"output": map[string]any{
+							"type":        "string",
+							"enum":        Outputs,
+							"description": "Parser for the agent's output: \"text\" (default) leaves it unparsed; \"claude-stream-json\" parses Claude Code's --output-format stream-json output and records cost, tokens, turns and the result per station run.",
+						},
+						"fallback": map[string]any{
+							"type":        "array",
+							"description": "Names of agent profiles tried in order when this agent is unavailable.",
+							"items":       map[string]any{"type": "string"},
+						},
+						"unavailable_exit_codes": map[string]any{
+							"type":        "array",
+							"description": "Exit codes meaning the agent is unavailable (e.g. rate-limited) rather than that it failed; the station falls back to its next agent. A command that is not installed always counts as unavailable.",
+							"items":       map[string]any{"type": "integer"},
+						},
+						"unavailable_patterns": map[string]any{
+							"type":        "array",
+							"description": "Regular expressions matched against the agent's stderr when it fails; a match means the agent is unavailable and the station falls back to its next agent.",
+							"items":       map[string]any{"type": "string"},
+						},
+					},
+				},
+			},
 			"settings": map[string]any{
 				"description": "Global settings for the assembly line.",
 				"type":        "object",
@@ -38,6 +123,105 @@ func Schema() []byte {
 						"type":        "string",
 						"description": "The Git branch to watch for new commits (e.g. \"main\" or \"master\"). When a commit lands on this branch, the line is triggered.",
 					},
+					"timeout": map[string]any{
+						"type":        "string",
+						"pattern":     durationPattern,
+						"description": "Default maximum run time for each station's agent, as a Go duration string (e.g. \"90s\", \"15m\", \"1h\"). When exceeded the agent's process group is sent SIGTERM, then SIGKILL after a grace period, and the station is marked \"timed out\". Overridden by station-level timeout. If omitted, agents run without a time limit.",
+					},
+					"retries": map[string]any{
+						"type":        "integer",
+						"minimum":     0,
+						"description": "Default number of times a failed station (non-zero exit or timeout) is retried before the line is blocked. Each retry starts from the pre-agent worktree state. Overridden by station-level retries. Defaults to 0.",
+					},
+					"retry_backoff": map[string]any{
+						"type":        "string",
+						"pattern":     durationPattern,
+						"description": "Delay before the first retry of a failed station, as a Go duration string. The delay doubles on each further retry, up to 10 minutes. Defaults to \"10s\".",
+					},
+					"quarantine": map[string]any{
+						"type":        "boolean",
+						"description": "Keep a station's rejected changes (e.g. failing verify) committed on refs/line/quarantine/<station> instead of discarding them. Overridden by station-level quarantine. Defaults to false.",
+					},
+					"gate_stations": map[string]any{
+						"type":        "boolean",
+						"description": "Run the gates in each station's worktree before its changes are committed. A failing gate rejects the changes like a failing verify command. Overridden by station-level gate. Defaults to false.",
+					},
+					"commit_message": map[string]any{
+						"type":        "string",
+						"description": "Go template for station commit messages, with .Station, .Agent, .Commit (the triggering commit), .Summary and .Body (written by the agent to $LINE_COMMIT_MSG_FILE, or a final \"Summary: ...\" output line). Defaults to \"assembly-line: station {{.Station}}{{if .Summary}}: {{.Summary}}{{end}}\" followed by the body. [skip line] is added to the subject line if missing. Overridden by station-level commit_message.",
+					},
+					"secrets": map[string]any{
+						"type":                 "object",
+						"additionalProperties": false,
+						"description":          "Built-in secret scanner, checking the lines added by each station's changes (and optionally by human commits) for credentials. Findings reject the station's changes.",
+						"properties": map[string]any{
+							"stations": map[string]any{
+								"type":        "boolean",
+								"description": "Scan each station's changes before they are committed. Defaults to true.",
+							},
+							"gate": map[string]any{
+								"type":        "boolean",
+								"description": "Also scan staged changes when line gate runs (the pre-commit hook), after the configured gates. Defaults to false.",
+							},
+							"allow": map[string]any{
+								"type":        "array",
+								"description": "Regular expressions for file paths or secrets that are not reported (e.g. \"EXAMPLE\", \"^testdata/\").",
+								"items":       map[string]any{"type": "string"},
+							},
+							"rules": map[string]any{
+								"type":        "array",
+								"description": "Rules added to the built-in ones.",
+								"items": map[string]any{
+									"type":                 "object",
+									"required":             []string{"name", "pattern"},
+									"additionalProperties": false,
+									"properties": map[string]any{
+										"name":    map[string]any{"type": "string", "description": "Name reported for findings."},
+										"pattern": map[string]any{"type": "string", "description": "Regular expression matching the secret."},
+										"entropy": map[string]any{"type": "number", "minimum": 0, "description": "Minimum Shannon entropy in bits per character of the secret (the pattern's first group, or else the whole match), filtering out placeholders."},
+									},
+								},
+							},
+						},
+					},
+					"budget": map[string]any{
+						"type":                 "object",
+						"additionalProperties": false,
+						"description":          "Spending limits, counting the cost and tokens parsed from agent output (see output). Once a limit is reached no further stations start; they are reported as \"budget exceeded\".",
+						"properties": map[string]any{
+							"station": map[string]any{
+								"type":                 "object",
+								"additionalProperties": false,
+								"description":          "Limit for a single station run, including its retries. A station over it is not retried.",
+								"properties": map[string]any{
+									"usd":    map[string]any{"type": "number", "minimum": 0, "description": "Limit in US dollars."},
+									"tokens": map[string]any{"type": "integer", "minimum": 0, "description": "Limit in tokens (input, output and cache)."},
+								},
+							},
+							"run": map[string]any{
+								"type":                 "object",
+								"additionalProperties": false,
+								"description":          "Limit for a whole line run.",
+								"properties": map[string]any{
+									"usd":    map[string]any{"type": "number", "minimum": 0, "description": "Limit in US dollars."},
+									"tokens": map[string]any{"type": "integer", "minimum": 0, "description": "Limit in tokens (input, output and cache)."},
+								},
+							},
+							"day": map[string]any{
+								"type":                 "object",
+								"additionalProperties": false,
+								"description":          "Limit for all line runs started on the same day.",
+								"properties": map[string]any{
+									"usd":    map[string]any{"type": "number", "minimum": 0, "description": "Limit in US dollars."},
+									"tokens": map[string]any{"type": "integer", "minimum": 0, "description": "Limit in tokens (input, output and cache)."},
+								},
+							},
+						},
+					},
+					"preamble": map[string]any{
+						"type":        "string",
+						"description": "Text prepended to every station's prompt, replacing the built-in preamble that tells the agent not to commit. An empty string disables the preamble. Overridden by station-level preamble.",
+					},
 				},
 			},
 			"gates": map[string]any{
@@ -60,29 +244,129 @@ func Schema() []byte {
 				},
 			},
 			"stations": map[string]any{
-				"description": "Ordered list of post-commit agent tasks. Each station runs on its own Git branch, in sequence. A station's command is resolved by checking station-level command first, then falling back to agent.command.",
+				"description": "Ordered list of post-commit agent tasks. Each station runs on its own Git branch, in sequence unless needs declares otherwise. A station's command is resolved by checking station-level command first, then falling back to agent.command.",
 				"type":        "array",
 				"items": map[string]any{
 					"type":     "object",
-					"required": []string{"name", "prompt"},
+					"required": []string{"name"},
 					"additionalProperties": false,
 					"properties": map[string]any{
 						"name": map[string]any{
 							"type":        "string",
 							"description": "Unique name for this station. Maps directly to a Git branch (line/stn/<name>). Must not duplicate another station name.",
 						},
+						"agent": map[string]any{
+							"type":        "string",
+							"description": "Name of an agents profile to use for this station. The station's own command, args, prompt_via and timeout override the profile's.",
+						},
 						"command": map[string]any{
 							"type":        "string",
-							"description": "Executable to run for this station, overriding agent.command. If omitted, agent.command is used.",
+							"description": "Executable to run for this station, overriding its agent profile and agent.command. If omitted, the profile's command or agent.command is used.",
 						},
 						"args": map[string]any{
 							"type":        "array",
-							"description": "Arguments for this station's command, overriding agent.args. The prompt is appended as the final argument. If omitted, agent.args is used.",
+							"description": "Arguments for this station's command, overriding agent.args. The prompt is appended as the final argument, unless prompt_via or a {{prompt}}/{{prompt_file}} placeholder says otherwise. If omitted, agent.args is used.",
 							"items":       map[string]any{"type": "string"},
 						},
 						"prompt": map[string]any{
 							"type":        "string",
-							"description": "The prompt text passed to the agent command as its final argument. Describes what this station should do.",
+							"description": "The prompt text passed to the agent command as its final argument. Describes what this station should do. Rendered as a Go template with the triggering commit's context: {{.Commit}}, {{.Message}}, {{.Author}}, {{.ChangedFiles}}, {{.Diffstat}}, {{.Diff}}, {{.Predecessor}} and {{.Station}}. {{include \"path\"}} inserts the contents of a file, relative to the config file. One of prompt or prompt_file is required.",
+						},
+						"prompt_file": map[string]any{
+							"type":        "string",
+							"description": "Path to a file holding this station's prompt, relative to the config file. Mutually exclusive with prompt. Inside the file, {{include \"path\"}} inserts another file, relative to the including file.",
+						},
+						"timeout": map[string]any{
+							"type":        "string",
+							"pattern":     durationPattern,
+							"description": "Maximum run time for this station's agent as a Go duration string (e.g. \"10m\"), overriding settings.timeout. \"0\" disables the timeout for this station.",
+						},
+						"retries": map[string]any{
+							"type":        "integer",
+							"minimum":     0,
+							"description": "Number of times this station is retried after a failed attempt, overriding settings.retries.",
+						},
+						"fallback": map[string]any{
+							"type":        "array",
+							"description": "Names of agent profiles tried in order when this station's agent is unavailable, overriding its profile's fallback.",
+							"items":       map[string]any{"type": "string"},
+						},
+						"output": map[string]any{
+							"type":        "string",
+							"enum":        Outputs,
+							"description": "Parser for this station's agent output, overriding its profile's and agent.output.",
+						},
+						"prompt_via": map[string]any{
+							"type":        "string",
+							"enum":        PromptVias,
+							"description": "How the prompt reaches this station's command (\"arg\", \"stdin\" or \"file\"), overriding agent.prompt_via.",
+						},
+						"commit_message": map[string]any{
+							"type":        "string",
+							"description": "Go template for this station's commit messages, overriding settings.commit_message. [skip line] is added to the subject line if missing.",
+						},
+						"preamble": map[string]any{
+							"type":        "string",
+							"description": "Text prepended to this station's prompt, overriding settings.preamble. An empty string disables the preamble for this station (e.g. for non-Claude tools or deterministic scripts).",
+						},
+						"needs": map[string]any{
+							"type":        "array",
+							"description": "Names of stations (declared earlier in the list) this station builds on. If omitted, the station needs the station before it, forming a chain. An empty list builds directly on the watched branch. Stations whose needs are satisfied run concurrently; a station needing several stations rebases onto their combined changes and fails if they conflict.",
+							"items":       map[string]any{"type": "string"},
+						},
+						"allowed_paths": map[string]any{
+							"type":        "array",
+							"description": "Gitignore-syntax patterns for the files this station's agent may change. Other changed files are out of scope (see path_policy).",
+							"items":       map[string]any{"type": "string"},
+						},
+						"forbidden_paths": map[string]any{
+							"type":        "array",
+							"description": "Gitignore-syntax patterns for files this station's agent must not change. Matching changed files are out of scope (see path_policy).",
+							"items":       map[string]any{"type": "string"},
+						},
+						"path_policy": map[string]any{
+							"type":        "string",
+							"enum":        PathPolicies,
+							"description": "What happens to changes outside allowed_paths or inside forbidden_paths: \"fail\" (default) rejects the station's changes and fails it; \"revert\" restores the out-of-scope files and commits the rest.",
+						},
+						"limits": map[string]any{
+							"type":                 "object",
+							"additionalProperties": false,
+							"description":          "Limits on the size of this station's changes, checked before verify and gates and again after them. Changes exceeding any limit are rejected (discarded or quarantined) and the station fails.",
+							"properties": map[string]any{
+								"files_changed": map[string]any{"type": "integer", "minimum": 0, "description": "Maximum number of files changed, added or deleted."},
+								"lines_added":   map[string]any{"type": "integer", "minimum": 0, "description": "Maximum number of lines added."},
+								"lines_removed": map[string]any{"type": "integer", "minimum": 0, "description": "Maximum number of lines removed."},
+								"deleted_files": map[string]any{"type": "integer", "minimum": 0, "description": "Maximum number of files deleted."},
+								"keep_files": map[string]any{
+									"type":        "array",
+									"description": "Gitignore-syntax patterns for files that must not be deleted (e.g. \"*_test.go\").",
+									"items":       map[string]any{"type": "string"},
+								},
+							},
+						},
+						"verify": map[string]any{
+							"type":        "array",
+							"description": "Shell commands run in order in the station's worktree after its agent succeeds. If one fails, the attempt fails; once retries are exhausted the agent's changes are discarded (or quarantined) and the station is marked failed.",
+							"items":       map[string]any{"type": "string"},
+						},
+						"gate": map[string]any{
+							"type":        "boolean",
+							"description": "Run the gates against this station's changes before committing them, overriding settings.gate_stations.",
+						},
+						"quarantine": map[string]any{
+							"type":        "boolean",
+							"description": "Keep this station's rejected changes on refs/line/quarantine/<station>, overriding settings.quarantine.",
+						},
+						"paths": map[string]any{
+							"type":        "array",
+							"description": "Gitignore-syntax patterns. The station only runs when a file changed by the triggering commit matches one of them; otherwise it is skipped.",
+							"items":       map[string]any{"type": "string"},
+						},
+						"paths_ignore": map[string]any{
+							"type":        "array",
+							"description": "Gitignore-syntax patterns for changed files that do not count towards running the station. The station is skipped when every changed file matches.",
+							"items":       map[string]any{"type": "string"},
 						},
 					},
 				},
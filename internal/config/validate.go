@@ -1,12 +1,43 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/prompt"
+)
 
 // Validate checks a loaded Config for semantic errors beyond what Load catches.
 // Returns a list of human/agent-readable error strings, one per issue.
 func Validate(cfg *Config) []string {
 	var errs []string
 
+	if err := prompt.Check(cfg.Agent.Prompt); err != nil {
+		errs = append(errs, fmt.Sprintf("agent.prompt: %v", err))
+	}
+
+	if cfg.Agent.PromptVia != "" && !slices.Contains(PromptVias, cfg.Agent.PromptVia) {
+		errs = append(errs, fmt.Sprintf("agent.prompt_via: %q is not one of %s", cfg.Agent.PromptVia, strings.Join(PromptVias, ", ")))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Agents)) {
+		profile := cfg.Agents[name]
+		if _, err := ParseDuration(profile.Timeout); err != nil {
+			errs = append(errs, fmt.Sprintf("agents.%s.timeout: %v", name, err))
+		}
+		if profile.PromptVia != "" && !slices.Contains(PromptVias, profile.PromptVia) {
+			errs = append(errs, fmt.Sprintf("agents.%s.prompt_via: %q is not one of %s", name, profile.PromptVia, strings.Join(PromptVias, ", ")))
+		}
+		errs = append(errs, validateOutput("agents."+name+".output", profile.Output)...)
+		errs = append(errs, validateFallback(cfg, "agents."+name+".fallback", profile.Fallback)...)
+		errs = append(errs, validateUnavailable("agents."+name, profile.Unavailable)...)
+	}
+	errs = append(errs, validateUnavailable("agent", cfg.Agent.Unavailable)...)
+	errs = append(errs, validateOutput("agent.output", cfg.Agent.Output)...)
+
 	seen := make(map[string]bool)
 	for i, s := range cfg.Stations {
 		if s.Name == "" {
@@ -18,11 +49,107 @@ func Validate(cfg *Config) []string {
 		}
 
 		if s.Prompt == "" {
-			errs = append(errs, fmt.Sprintf("stations[%d].prompt: required field is empty", i))
+			errs = append(errs, fmt.Sprintf("stations[%d].prompt: required field is empty (set prompt or prompt_file)", i))
+		} else if err := prompt.Check(s.Prompt); err != nil {
+			errs = append(errs, fmt.Sprintf("stations[%d].prompt: %v", i, err))
+		}
+
+		if _, ok := cfg.Agents[s.Agent]; s.Agent != "" && !ok {
+			errs = append(errs, fmt.Sprintf("stations[%d].agent: unknown agent profile %q (defined in agents: %s)", i, s.Agent, strings.Join(slices.Sorted(maps.Keys(cfg.Agents)), ", ")))
+		} else if cfg.ResolveStation(s).Command == "" {
+			errs = append(errs, fmt.Sprintf("stations[%d]: no resolvable command (set station command, its agent profile's command or agent.command)", i))
+		}
+
+		if _, err := ParseDuration(s.Timeout); err != nil {
+			errs = append(errs, fmt.Sprintf("stations[%d].timeout: %v", i, err))
+		}
+
+		if s.PromptVia != "" && !slices.Contains(PromptVias, s.PromptVia) {
+			errs = append(errs, fmt.Sprintf("stations[%d].prompt_via: %q is not one of %s", i, s.PromptVia, strings.Join(PromptVias, ", ")))
+		}
+
+		errs = append(errs, validateFallback(cfg, fmt.Sprintf("stations[%d].fallback", i), s.Fallback)...)
+		errs = append(errs, validateOutput(fmt.Sprintf("stations[%d].output", i), s.Output)...)
+
+		if s.Retries != nil && *s.Retries < 0 {
+			errs = append(errs, fmt.Sprintf("stations[%d].retries: must not be negative", i))
+		}
+		if s.PathPolicy != "" && !slices.Contains(PathPolicies, s.PathPolicy) {
+			errs = append(errs, fmt.Sprintf("stations[%d].path_policy: %q is not one of %s", i, s.PathPolicy, strings.Join(PathPolicies, ", ")))
+		}
+		for _, limit := range []struct {
+			field string
+			value *int
+		}{
+			{"files_changed", s.Limits.FilesChanged},
+			{"lines_added", s.Limits.LinesAdded},
+			{"lines_removed", s.Limits.LinesRemoved},
+			{"deleted_files", s.Limits.DeletedFiles},
+		} {
+			if limit.value != nil && *limit.value < 0 {
+				errs = append(errs, fmt.Sprintf("stations[%d].limits.%s: must not be negative", i, limit.field))
+			}
+		}
+		if s.CommitMessage != "" {
+			if err := prompt.CheckCommit(s.CommitMessage); err != nil {
+				errs = append(errs, fmt.Sprintf("stations[%d].commit_message: %v", i, err))
+			}
+		}
+		for j, command := range s.Verify {
+			if strings.TrimSpace(command) == "" {
+				errs = append(errs, fmt.Sprintf("stations[%d].verify[%d]: required field is empty", i, j))
+			}
+		}
+
+		// Needs must refer to stations declared earlier, which rules out
+		// cycles and keeps config order a valid run order.
+		for j, need := range s.Needs {
+			if !seen[need] || need == s.Name {
+				errs = append(errs, fmt.Sprintf("stations[%d].needs[%d]: %q is not a station declared before %q", i, j, need, s.Name))
+			}
+		}
+	}
+
+	if _, err := ParseDuration(cfg.Settings.Timeout); err != nil {
+		errs = append(errs, fmt.Sprintf("settings.timeout: %v", err))
+	}
+	if cfg.Settings.Retries < 0 {
+		errs = append(errs, "settings.retries: must not be negative")
+	}
+	if _, err := ParseDuration(cfg.Settings.RetryBackoff); err != nil {
+		errs = append(errs, fmt.Sprintf("settings.retry_backoff: %v", err))
+	}
+	if cfg.Settings.CommitMessage != "" {
+		if err := prompt.CheckCommit(cfg.Settings.CommitMessage); err != nil {
+			errs = append(errs, fmt.Sprintf("settings.commit_message: %v", err))
 		}
+	}
+	budget := cfg.Settings.Budget
+	for _, limit := range []struct {
+		field string
+		Limit
+	}{{"station", budget.Station}, {"run", budget.Run}, {"day", budget.Day}} {
+		if limit.USD < 0 || limit.Tokens < 0 {
+			errs = append(errs, fmt.Sprintf("settings.budget.%s: must not be negative", limit.field))
+		}
+	}
 
-		if s.Command == "" && cfg.Agent.Command == "" {
-			errs = append(errs, fmt.Sprintf("stations[%d]: no resolvable command (set station command or agent.command)", i))
+	for i, r := range cfg.Settings.Secrets.Rules {
+		if r.Name == "" {
+			errs = append(errs, fmt.Sprintf("settings.secrets.rules[%d].name: required field is empty", i))
+		}
+		if r.Pattern == "" {
+			errs = append(errs, fmt.Sprintf("settings.secrets.rules[%d].pattern: required field is empty", i))
+		} else if _, err := regexp.Compile(r.Pattern); err != nil {
+			errs = append(errs, fmt.Sprintf("settings.secrets.rules[%d].pattern: %v", i, err))
+		}
+		if r.Entropy < 0 {
+			errs = append(errs, fmt.Sprintf("settings.secrets.rules[%d].entropy: must not be negative", i))
+		}
+	}
+	for i, a := range cfg.Settings.Secrets.Allow {
+		if _, err := regexp.Compile(a); err != nil {
+			errs = append(errs, fmt.Sprintf("settings.secrets.allow[%d]: %v", i, err))
 		}
 	}
 
@@ -37,3 +164,34 @@ func Validate(cfg *Config) []string {
 
 	return errs
 }
+
+// validateFallback checks that a fallback list only names agent profiles.
+func validateFallback(cfg *Config, field string, fallback []string) []string {
+	var errs []string
+	for j, name := range fallback {
+		if _, ok := cfg.Agents[name]; !ok {
+			errs = append(errs, fmt.Sprintf("%s[%d]: unknown agent profile %q", field, j, name))
+		}
+	}
+	return errs
+}
+
+// validateUnavailable checks that unavailable patterns are valid regular
+// expressions.
+func validateUnavailable(field string, u Unavailable) []string {
+	var errs []string
+	for j, pattern := range u.UnavailablePatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, fmt.Sprintf("%s.unavailable_patterns[%d]: %v", field, j, err))
+		}
+	}
+	return errs
+}
+
+// validateOutput checks that an output parser name is known.
+func validateOutput(field, output string) []string {
+	if output == "" || slices.Contains(Outputs, output) {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s: %q is not one of %s", field, output, strings.Join(Outputs, ", "))}
+}
@@ -2,6 +2,7 @@ package gate
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 )
@@ -13,12 +14,17 @@ type Gate struct {
 
 // RunGates executes gates in order, failing fast on the first error.
 func RunGates(gates []Gate, dir string) error {
+	return RunGatesTo(gates, dir, os.Stdout, os.Stderr)
+}
+
+// RunGatesTo is RunGates with the gates' output written to stdout and stderr.
+func RunGatesTo(gates []Gate, dir string, stdout, stderr io.Writer) error {
 	for _, g := range gates {
-		fmt.Fprintf(os.Stderr, "gate: running %s\n", g.Name)
+		fmt.Fprintf(stderr, "gate: running %s\n", g.Name)
 		cmd := exec.Command("sh", "-c", g.Run)
 		cmd.Dir = dir
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
 		if err := cmd.Run(); err != nil {
 			return fmt.Errorf("gate %q failed: %w", g.Name, err)
 		}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -84,6 +85,18 @@ func RebaseAbort(dir string) error {
 	return err
 }
 
+// Checkout switches the working tree to the given branch.
+func Checkout(dir, branch string) error {
+	_, err := Run(dir, "checkout", branch)
+	return err
+}
+
+// CheckoutDetached switches the working tree to ref with a detached HEAD.
+func CheckoutDetached(dir, ref string) error {
+	_, err := Run(dir, "checkout", "--detach", ref)
+	return err
+}
+
 // CommitAll stages all changes and commits with the given message.
 // It excludes the .line/ directory which contains runtime state.
 func CommitAll(dir, message string) error {
@@ -92,18 +105,109 @@ func CommitAll(dir, message string) error {
 	}
 	// Unstage .line/ - it's runtime state, not project code
 	_, _ = Run(dir, "reset", "--", ".line/")
-	// Check if there's anything to commit
-	status, err := Run(dir, "status", "--porcelain")
+	// Check if there's anything to commit; untracked .line/ doesn't count
+	staged, err := Run(dir, "diff", "--cached", "--name-only")
 	if err != nil {
 		return err
 	}
-	if status == "" {
+	if staged == "" {
 		return nil // Nothing to commit
 	}
 	_, err = Run(dir, "commit", "-m", message)
 	return err
 }
 
+// StageAll stages all changes in the working tree except .line/.
+func StageAll(dir string) error {
+	if _, err := Run(dir, "add", "-A"); err != nil {
+		return err
+	}
+	_, _ = Run(dir, "reset", "--", ".line/")
+	return nil
+}
+
+// ChangedFiles stages all changes except .line/ and returns the paths that
+// differ from HEAD. Renamed files are listed under both paths.
+func ChangedFiles(dir string) ([]string, error) {
+	if err := StageAll(dir); err != nil {
+		return nil, err
+	}
+	out, err := Run(dir, "-c", "core.quotePath=false", "diff", "--cached", "--name-only", "--no-renames", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// StagedDiff returns the diff of the staged changes against HEAD, without
+// context lines.
+func StagedDiff(dir string) (string, error) {
+	return Run(dir, "-c", "core.quotePath=false", "diff", "--cached", "--no-color", "--no-ext-diff", "--no-renames", "-U0")
+}
+
+// FileChange describes how a file differs from HEAD.
+type FileChange struct {
+	Path    string
+	Added   int // lines added; 0 for binary files
+	Removed int // lines removed; 0 for binary files
+	Deleted bool
+}
+
+// StagedChanges stages all changes except .line/ and returns how each file
+// differs from HEAD. Renamed files are listed as a deletion and an addition.
+func StagedChanges(dir string) ([]FileChange, error) {
+	if err := StageAll(dir); err != nil {
+		return nil, err
+	}
+	numstat, err := Run(dir, "-c", "core.quotePath=false", "diff", "--cached", "--numstat", "--no-renames", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := Run(dir, "-c", "core.quotePath=false", "diff", "--cached", "--name-only", "--no-renames", "--diff-filter=D", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	isDeleted := make(map[string]bool)
+	for _, path := range strings.Split(deleted, "\n") {
+		isDeleted[path] = path != ""
+	}
+	var changes []FileChange
+	for _, line := range strings.Split(numstat, "\n") {
+		// "<added>\t<removed>\t<path>", with "-" counts for binary files
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		added, _ := strconv.Atoi(fields[0])
+		removed, _ := strconv.Atoi(fields[1])
+		changes = append(changes, FileChange{Path: fields[2], Added: added, Removed: removed, Deleted: isDeleted[fields[2]]})
+	}
+	return changes, nil
+}
+
+// RevertFiles restores the given paths to their state at HEAD, removing
+// those that don't exist there.
+func RevertFiles(dir string, files []string) error {
+	if _, err := Run(dir, append([]string{"reset", "-q", "HEAD", "--"}, files...)...); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if _, err := Run(dir, "cat-file", "-e", "HEAD:"+f); err != nil {
+			if err := os.Remove(filepath.Join(dir, f)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if _, err := Run(dir, "checkout", "HEAD", "--", f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // HeadShortRef returns the short ref of HEAD.
 func HeadShortRef(dir string) (string, error) {
 	return Run(dir, "rev-parse", "--short", "HEAD")
@@ -135,17 +239,67 @@ func LastCommitMessage(dir string) (string, error) {
 	return Run(dir, "log", "-1", "--format=%s")
 }
 
+// BlameLine is a line of a file and the commit that last changed it.
+type BlameLine struct {
+	Commit string // full SHA
+	Line   int    // line number in the file at rev
+	Text   string
+}
+
+// Blame returns each line of file as of rev and the commit that last changed
+// it.
+func Blame(dir, rev, file string) ([]BlameLine, error) {
+	out, err := Run(dir, "blame", "--line-porcelain", rev, "--", file)
+	if err != nil {
+		return nil, err
+	}
+	var lines []BlameLine
+	var cur BlameLine
+	for _, l := range strings.Split(out, "\n") {
+		if text, ok := strings.CutPrefix(l, "\t"); ok {
+			cur.Text = text
+			lines = append(lines, cur)
+			cur = BlameLine{}
+			continue
+		}
+		// Each line starts with "<sha> <orig line> <final line> [<count>]"
+		fields := strings.Fields(l)
+		if cur.Commit == "" && len(fields) >= 3 && len(fields[0]) >= 40 {
+			cur.Commit = fields[0]
+			cur.Line, _ = strconv.Atoi(fields[2])
+		}
+	}
+	return lines, nil
+}
+
 // StationBranchName returns the branch name for a station.
 func StationBranchName(name string) string {
 	return "line/stn/" + name
 }
 
+// QuarantineRef returns the ref holding a station's rejected changes.
+func QuarantineRef(name string) string {
+	return "refs/line/quarantine/" + name
+}
+
+// UpdateRef points ref at the given commit.
+func UpdateRef(dir, ref, commit string) error {
+	_, err := Run(dir, "update-ref", ref, commit)
+	return err
+}
+
 // ResetHard resets the current branch to the given ref.
 func ResetHard(dir, ref string) error {
 	_, err := Run(dir, "reset", "--hard", ref)
 	return err
 }
 
+// CleanAll removes all untracked and ignored files from the working tree.
+func CleanAll(dir string) error {
+	_, err := Run(dir, "clean", "-fdx")
+	return err
+}
+
 // IsAncestor returns true if ancestor's HEAD is reachable from descendant.
 func IsAncestor(dir, ancestor, descendant string) bool {
 	_, err := Run(dir, "merge-base", "--is-ancestor", ancestor, descendant)
@@ -161,6 +315,15 @@ func HasCommitsBetween(dir, from, to string) (bool, error) {
 	return out != "0", nil
 }
 
+// CountCommitsBetween returns the number of commits in from..to.
+func CountCommitsBetween(dir, from, to string) (int, error) {
+	out, err := Run(dir, "rev-list", "--count", from+".."+to)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(out)
+}
+
 // OnlySkipCommitsBetween returns true if from..to contains at least one commit
 // and every commit message contains a skip marker.
 func OnlySkipCommitsBetween(dir, from, to string, skipMarkers []string) bool {
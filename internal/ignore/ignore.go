@@ -29,6 +29,19 @@ func Load(dir string) (*Matcher, error) {
 	return &Matcher{gi: gi}, nil
 }
 
+// New returns a Matcher for the given gitignore-syntax patterns.
+func New(patterns []string) *Matcher {
+	if len(patterns) == 0 {
+		return &Matcher{}
+	}
+	return &Matcher{gi: gitignore.CompileIgnoreLines(patterns...)}
+}
+
+// Matches returns true if the file path matches the patterns.
+func (m *Matcher) Matches(file string) bool {
+	return m.gi != nil && m.gi.MatchesPath(file)
+}
+
 // AllIgnored returns true if all given file paths match the ignore patterns.
 func (m *Matcher) AllIgnored(files []string) bool {
 	if m.gi == nil {
@@ -0,0 +1,168 @@
+package prompt
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Vars are the variables available to station prompt templates (RUN-25).
+type Vars struct {
+	Commit       string   // SHA of the triggering commit
+	Message      string   // full message of the triggering commit
+	Author       string   // author of the triggering commit, "Name <email>"
+	ChangedFiles []string // files changed by the triggering commit
+	Diffstat     string   // diffstat of the triggering commit
+	Diff         string   // full diff of the triggering commit
+	Predecessor  string   // branch(es) the station builds on
+	Station      string   // station name
+}
+
+// CommitVars are the variables available to commit message templates
+// (RUN-36).
+type CommitVars struct {
+	Station string // station name
+	Agent   string // agent profile name, or the command without one
+	Commit  string // SHA of the triggering commit
+	Summary string // one-line summary written by the agent, may be empty
+	Body    string // further description written by the agent, may be empty
+}
+
+// Names returns the template names of all variables, e.g. ".Commit".
+func Names() []string {
+	return fieldNames(Vars{})
+}
+
+// CommitNames returns the template names of all commit message variables.
+func CommitNames() []string {
+	return fieldNames(CommitVars{})
+}
+
+// fieldNames returns the template names of a struct's fields.
+func fieldNames(v any) []string {
+	t := reflect.TypeOf(v)
+	names := make([]string, t.NumField())
+	for i := range names {
+		names[i] = "." + t.Field(i).Name
+	}
+	return names
+}
+
+// Parse parses a prompt as a Go template.
+func Parse(text string) (*template.Template, error) {
+	return template.New("prompt").Parse(text)
+}
+
+// Check reports template parse errors and references to unknown variables.
+func Check(text string) error {
+	return check(text, Names())
+}
+
+// CheckCommit reports commit message template parse errors and references to
+// unknown variables.
+func CheckCommit(text string) error {
+	return check(text, CommitNames())
+}
+
+// check reports template parse errors and references to variables other than
+// names.
+func check(text string, names []string) error {
+	tmpl, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	if tmpl.Tree == nil {
+		return nil
+	}
+	known := make(map[string]bool)
+	for _, name := range names {
+		known[strings.TrimPrefix(name, ".")] = true
+	}
+	var unknown []string
+	walk(tmpl.Tree.Root, true, func(field string) {
+		if !known[field] {
+			unknown = append(unknown, "."+field)
+		}
+	})
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown variable %s (available: %s)", strings.Join(unknown, ", "), strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// walk calls fn with the first field name of every reference to the
+// top-level data (".Field" or "$.Field"). Inside range and with blocks dot is
+// rebound, so there only "$.Field" references are checked.
+func walk(node parse.Node, dotIsData bool, fn func(field string)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			walk(c, dotIsData, fn)
+		}
+	case *parse.ActionNode:
+		walk(n.Pipe, dotIsData, fn)
+	case *parse.IfNode:
+		walk(n.Pipe, dotIsData, fn)
+		walk(n.List, dotIsData, fn)
+		walk(n.ElseList, dotIsData, fn)
+	case *parse.RangeNode:
+		walk(n.Pipe, dotIsData, fn)
+		walk(n.List, false, fn)
+		walk(n.ElseList, dotIsData, fn)
+	case *parse.WithNode:
+		walk(n.Pipe, dotIsData, fn)
+		walk(n.List, false, fn)
+		walk(n.ElseList, dotIsData, fn)
+	case *parse.TemplateNode:
+		walk(n.Pipe, dotIsData, fn)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			walk(c, dotIsData, fn)
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			walk(a, dotIsData, fn)
+		}
+	case *parse.ChainNode:
+		walk(n.Node, dotIsData, fn)
+	case *parse.FieldNode:
+		if dotIsData {
+			fn(n.Ident[0])
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			fn(n.Ident[1])
+		}
+	}
+}
+
+// Render executes a prompt template with the given variables.
+func Render(text string, vars Vars) (string, error) {
+	return render(text, vars)
+}
+
+// RenderCommit executes a commit message template with the given variables.
+func RenderCommit(text string, vars CommitVars) (string, error) {
+	return render(text, vars)
+}
+
+// render executes a template with the given data.
+func render(text string, data any) (string, error) {
+	tmpl, err := Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,112 @@
+// Package provenance records where station commits come from as git trailers
+// and follows them back to the human commit that triggered a change (TRC-1).
+package provenance
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/git"
+)
+
+// Trailer keys added to every station commit (TRC-1).
+const (
+	StationKey = "Line-Station"
+	SourceKey  = "Line-Source-Commit"
+	RunKey     = "Line-Run-Id"
+	AgentKey   = "Line-Agent"
+)
+
+// Trailers describe what produced a station commit.
+type Trailers struct {
+	Station string // station name
+	Source  string // SHA of the watched branch commit the run was triggered by
+	RunID   string // line run ID, as in the history ledger
+	Agent   string // agent profile name, or the command without one
+}
+
+// Append returns msg with the trailers added as its final paragraph. Empty
+// values are left out.
+func Append(msg string, t Trailers) string {
+	var lines []string
+	for _, kv := range [][2]string{{StationKey, t.Station}, {SourceKey, t.Source}, {RunKey, t.RunID}, {AgentKey, t.Agent}} {
+		if v := strings.TrimSpace(kv[1]); v != "" {
+			lines = append(lines, kv[0]+": "+v)
+		}
+	}
+	if len(lines) == 0 {
+		return msg
+	}
+	return strings.TrimRight(msg, "\n") + "\n\n" + strings.Join(lines, "\n")
+}
+
+// Origin is a commit and, for a station commit, its trailers.
+type Origin struct {
+	Commit   string // full SHA
+	Subject  string
+	Author   string // "Name <email>"
+	Trailers        // empty unless the commit was made by a station
+}
+
+// IsStation reports whether the commit was made by a station.
+func (o Origin) IsStation() bool {
+	return o.Station != ""
+}
+
+// Read returns the origin of a commit.
+func Read(dir, rev string) (Origin, error) {
+	out, err := git.Run(dir, "show", "-s", "--format=%H%x00%s%x00%an <%ae>%x00%(trailers:only,unfold)", rev+"^{commit}", "--")
+	if err != nil {
+		return Origin{}, fmt.Errorf("unknown commit %q", rev)
+	}
+	fields := strings.SplitN(out, "\x00", 4)
+	if len(fields) != 4 {
+		return Origin{}, fmt.Errorf("reading commit %q: unexpected output", rev)
+	}
+	o := Origin{Commit: fields[0], Subject: fields[1], Author: fields[2]}
+	for _, line := range strings.Split(fields[3], "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case StationKey:
+			o.Station = value
+		case SourceKey:
+			o.Source = value
+		case RunKey:
+			o.RunID = value
+		case AgentKey:
+			o.Agent = value
+		}
+	}
+	return o, nil
+}
+
+// Trace follows a commit's trailers back to the human commit that triggered
+// it: the commit's origin, then its source's, and so on, ending with the
+// first commit not made by a station. found is false when a station
+// commit's source no longer exists, e.g. after history was rewritten.
+func Trace(dir, rev string) (chain []Origin, found bool, err error) {
+	o, err := Read(dir, rev)
+	if err != nil {
+		return nil, false, err
+	}
+	seen := make(map[string]bool)
+	for {
+		chain = append(chain, o)
+		seen[o.Commit] = true
+		if !o.IsStation() {
+			return chain, true, nil
+		}
+		if o.Source == "" {
+			return chain, false, nil
+		}
+		next, err := Read(dir, o.Source)
+		if err != nil || seen[next.Commit] {
+			return chain, false, nil
+		}
+		o = next
+	}
+}
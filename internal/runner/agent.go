@@ -1,52 +1,253 @@
 package runner
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// Placeholders that may appear anywhere in an agent's args (RUN-27).
+const (
+	promptPlaceholder     = "{{prompt}}"
+	promptFilePlaceholder = "{{prompt_file}}"
 )
 
-const preamble = "IMPORTANT: Do NOT commit any changes. Do NOT run git commit. Make file changes only. The system will handle committing."
+// fullPrompt returns the prompt with the preamble prepended (RUN-12). An empty
+// preamble is disabled (RUN-26).
+func fullPrompt(preamble, prompt string) string {
+	if preamble == "" {
+		return prompt
+	}
+	return preamble + "\n\n" + prompt
+}
+
+// hasPlaceholder reports whether any arg contains a prompt placeholder.
+func hasPlaceholder(args []string) bool {
+	for _, a := range args {
+		if strings.Contains(a, promptPlaceholder) || strings.Contains(a, promptFilePlaceholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// agentArgs returns the full argument list for an agent (RUN-27): the
+// configured args with placeholders replaced by the prompt and the prompt
+// file path. Without placeholders, the prompt (via arg) or the prompt file
+// path (via file) is appended as the final argument; via stdin appends
+// nothing.
+func agentArgs(args []string, via, prompt, promptFile string) []string {
+	fullArgs := make([]string, 0, len(args)+1)
+	replacer := strings.NewReplacer(promptPlaceholder, prompt, promptFilePlaceholder, promptFile)
+	for _, a := range args {
+		fullArgs = append(fullArgs, replacer.Replace(a))
+	}
+	if hasPlaceholder(args) {
+		return fullArgs
+	}
+	switch via {
+	case config.PromptViaFile:
+		return append(fullArgs, promptFile)
+	case config.PromptViaStdin:
+		return fullArgs
+	default:
+		return append(fullArgs, prompt)
+	}
+}
+
+// needsPromptFile reports whether an agent invocation reads its prompt from
+// a file.
+func needsPromptFile(args []string, via string) bool {
+	for _, a := range args {
+		if strings.Contains(a, promptFilePlaceholder) {
+			return true
+		}
+	}
+	return !hasPlaceholder(args) && via == config.PromptViaFile
+}
 
 // agentProcess represents a running agent subprocess.
 type agentProcess struct {
-	cmd *exec.Cmd
+	cmd        *exec.Cmd
+	promptFile string            // removed once the agent exits
+	stdout     *tailBuffer       // recent stdout, checked for a summary (RUN-36)
+	stderr     *tailBuffer       // recent stderr, checked for unavailability (RUN-28)
+	output     *streamJSONParser // nil unless the agent's output is parsed (RUN-29)
+}
+
+// stderrTail is how much of an agent's stderr is kept for matching
+// unavailable patterns, and of its stdout for finding its summary.
+const stderrTail = 64 * 1024
+
+// tailBuffer is an io.Writer that keeps only the last stderrTail bytes.
+type tailBuffer struct {
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - stderrTail; over > 0 {
+		t.buf = t.buf[over:]
+	}
+	return len(p), nil
 }
 
-// startAgent launches an agent subprocess with the given command, args, and prompt.
-// The agent runs in its own process group for clean cleanup.
-// RUN-12: The preamble is prepended to the prompt.
-func startAgent(dir, command string, args []string, prompt string) (*agentProcess, error) {
-	fullPrompt := preamble + "\n\n" + prompt
-	fullArgs := make([]string, len(args))
-	copy(fullArgs, args)
-	fullArgs = append(fullArgs, fullPrompt)
+// Bytes returns the buffered output.
+func (t *tailBuffer) Bytes() []byte {
+	return t.buf
+}
 
-	cmd := exec.Command(command, fullArgs...)
+// startAgent launches a station's agent subprocess with its resolved command,
+// args and environment, delivering the prompt as its prompt_via says
+// (RUN-27). The agent runs in its own process group for clean cleanup.
+// RUN-12: The prompt must already include the preamble (see fullPrompt).
+// LOG-1: The agent's combined stdout/stderr is also written to log.
+func startAgent(dir string, resolved config.ResolvedStation, prompt string, log io.Writer) (*agentProcess, error) {
+	command, args, via := resolved.Command, resolved.Args, resolved.PromptVia
+	var promptFile string
+	if needsPromptFile(args, via) {
+		// Outside the worktree, so the prompt is never committed
+		f, err := os.CreateTemp("", "line-prompt-*.md")
+		if err != nil {
+			return nil, fmt.Errorf("writing prompt file: %w", err)
+		}
+		promptFile = f.Name()
+		_, err = f.WriteString(prompt)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(promptFile)
+			return nil, fmt.Errorf("writing prompt file: %w", err)
+		}
+	}
+
+	// RUN-36: Give the agent a place for its commit message, clearing any
+	// left by an earlier attempt
+	msgFile := filepath.Join(dir, commitMsgFile)
+	_ = os.Remove(msgFile)
+	if err := os.MkdirAll(filepath.Dir(msgFile), 0o755); err != nil {
+		return nil, fmt.Errorf("creating commit message directory: %w", err)
+	}
+
+	cmd := exec.Command(command, agentArgs(args, via, prompt, promptFile)...)
 	cmd.Dir = dir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	if via == config.PromptViaStdin {
+		cmd.Stdin = strings.NewReader(prompt)
+	}
+	stdoutTail := &tailBuffer{}
+	stdout := io.MultiWriter(os.Stdout, log, stdoutTail)
+	var output *streamJSONParser
+	if resolved.Output == config.OutputClaudeStreamJSON {
+		output = &streamJSONParser{}
+		stdout = io.MultiWriter(stdout, output)
+	}
+	cmd.Stdout = stdout
+	stderr := &tailBuffer{}
+	cmd.Stderr = io.MultiWriter(os.Stderr, log, stderr)
 
 	// Build a clean environment for the agent:
 	// - Remove CLAUDECODE so Claude Code can launch as a fresh session
+	// - Add the agent profile's environment (CFG-STN-14)
 	// - Set LINE_RUNNING=1 to prevent retriggering
-	env := cleanEnv(os.Environ(), "CLAUDECODE")
-	cmd.Env = append(env, "LINE_RUNNING=1")
+	// - Point LINE_COMMIT_MSG_FILE at the commit message file (RUN-36)
+	env := cleanEnv(os.Environ(), "CLAUDECODE", commitMsgEnv)
+	env = append(env, resolved.Env...)
+	cmd.Env = append(env, "LINE_RUNNING=1", commitMsgEnv+"="+msgFile)
 
 	// Set process group so we can kill the whole group
 	setProcGroup(cmd)
 
+	// LOG-1: Output is copied through pipes, which descendants the agent
+	// leaves behind (e.g. "sleep 20 &") or that escape its process group
+	// hold open; don't wait for them once the agent has exited
+	cmd.WaitDelay = outputWaitDelay
+
 	if err := cmd.Start(); err != nil {
+		if promptFile != "" {
+			_ = os.Remove(promptFile)
+		}
 		return nil, fmt.Errorf("starting agent %q: %w", command, err)
 	}
 
-	return &agentProcess{cmd: cmd}, nil
+	return &agentProcess{cmd: cmd, promptFile: promptFile, stdout: stdoutTail, stderr: stderr, output: output}, nil
+}
+
+// outputWaitDelay is how long to keep copying an agent's output after it
+// has exited, while processes it started still hold its stdout or stderr.
+const outputWaitDelay = 3 * time.Second
+
+// terminateGrace is how long a timed-out agent is given to exit after SIGTERM
+// before it is sent SIGKILL.
+const terminateGrace = 10 * time.Second
+
+// errTimedOut is returned when an agent exceeds its station timeout.
+var errTimedOut = errors.New("timed out")
+
+// wait waits for the agent to finish. If timeout is positive and elapses
+// first, the agent's process group is sent SIGTERM, then SIGKILL after
+// terminateGrace, and errTimedOut is returned.
+func (a *agentProcess) wait(timeout time.Duration) error {
+	if a.promptFile != "" {
+		defer os.Remove(a.promptFile)
+	}
+	done := make(chan error, 1)
+	go func() {
+		err := a.cmd.Wait()
+		if errors.Is(err, exec.ErrWaitDelay) {
+			err = nil // the agent itself succeeded; see outputWaitDelay
+		}
+		done <- err
+	}()
+	if timeout <= 0 {
+		return <-done
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+	}
+
+	_ = terminateProcGroup(a.cmd)
+	select {
+	case <-done:
+		return errTimedOut
+	case <-time.After(terminateGrace):
+	}
+	_ = killProcGroup(a.cmd)
+	<-done
+	return errTimedOut
+}
+
+// usage returns what the agent reported it consumed (RUN-29), and false when
+// its output isn't parsed or holds no result.
+func (a *agentProcess) usage() (state.Usage, bool) {
+	if a.output == nil {
+		return state.Usage{}, false
+	}
+	return a.output.result()
 }
 
-// wait waits for the agent to finish.
-func (a *agentProcess) wait() error {
-	return a.cmd.Wait()
+// finalLine returns the last line of the agent's output (RUN-36): of its
+// result text when its output is parsed, or else of its stdout.
+func (a *agentProcess) finalLine() string {
+	if a.output != nil {
+		if u, ok := a.output.result(); ok {
+			return lastLine(u.Result)
+		}
+	}
+	return lastLine(string(a.stdout.Bytes()))
 }
 
 // pid returns the process ID of the agent.
@@ -57,6 +258,19 @@ func (a *agentProcess) pid() int {
 	return 0
 }
 
+// exitCode returns the exit code for an agent's wait error: 0 on success, the
+// process exit code when it exited, or -1 when it never ran or was signalled.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
 // cleanEnv returns a copy of environ with the named variables removed.
 func cleanEnv(environ []string, keys ...string) []string {
 	result := make([]string, 0, len(environ))
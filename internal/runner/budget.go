@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// budget tracks what a line run's agents have spent against the configured
+// limits (RUN-30). Usage is only known for agents whose output is parsed
+// (RUN-29). It is safe for concurrent use.
+type budget struct {
+	limits config.Budget
+
+	mu       sync.Mutex
+	run      state.Usage // spent by this run
+	day      state.Usage // spent by all runs started today, including this one
+	exceeded string      // why the budget is exhausted, "" while within it
+}
+
+// newBudget returns a budget for a run starting at start, counting usage
+// already recorded today in the history ledger.
+func newBudget(dir string, limits config.Budget, start time.Time) *budget {
+	b := &budget{limits: limits}
+	runs, _ := state.ReadHistory(dir)
+	year, month, day := start.Local().Date()
+	for _, run := range runs {
+		if y, m, d := run.Start.Local().Date(); y != year || m != month || d != day {
+			continue
+		}
+		for _, s := range run.Stations {
+			if s.Usage != nil {
+				b.day.Add(*s.Usage)
+			}
+		}
+	}
+	b.exceeded = exhausted("day", limits.Day, b.day)
+	return b
+}
+
+// add records a finished station's usage, returning why the budget is now
+// exhausted, if it is.
+func (b *budget) add(usage *state.Usage) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if usage != nil {
+		b.run.Add(*usage)
+		b.day.Add(*usage)
+		if b.exceeded == "" {
+			b.exceeded = exhausted("station", b.limits.Station, *usage)
+		}
+	}
+	if b.exceeded == "" {
+		b.exceeded = exhausted("run", b.limits.Run, b.run)
+	}
+	if b.exceeded == "" {
+		b.exceeded = exhausted("day", b.limits.Day, b.day)
+	}
+	return b.exceeded
+}
+
+// exhaustedReason returns why the budget is exhausted, or "" while within it.
+func (b *budget) exhaustedReason() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exceeded
+}
+
+// exhausted describes how usage reaches a limit, or returns "" when it
+// doesn't, e.g. "run budget of $5.00 exhausted ($5.12 spent)".
+func exhausted(scope string, limit config.Limit, usage state.Usage) string {
+	if limit.USD > 0 && usage.CostUSD >= limit.USD {
+		return fmt.Sprintf("%s budget of $%.2f exhausted ($%.2f spent)", scope, limit.USD, usage.CostUSD)
+	}
+	if limit.Tokens > 0 && usage.Tokens() >= limit.Tokens {
+		return fmt.Sprintf("%s budget of %d tokens exhausted (%d used)", scope, limit.Tokens, usage.Tokens())
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/prompt"
+	"github.com/re-cinq/assembly-line/internal/provenance"
+)
+
+// commitMsgFile is where an agent may write a commit message for its changes,
+// relative to the station's worktree (RUN-36). It lies under .line/, so it is
+// never committed. Agents find it through commitMsgEnv.
+const commitMsgFile = ".line/commit-msg"
+
+// commitMsgEnv names the environment variable holding the absolute path of
+// commitMsgFile.
+const commitMsgEnv = "LINE_COMMIT_MSG_FILE"
+
+// summaryPrefix marks a final output line that summarises the agent's
+// changes, e.g. "Summary: Fix typos in README" (RUN-36).
+const summaryPrefix = "Summary:"
+
+// agentMessage returns the summary and body an agent wrote for its changes
+// (RUN-36): the first line and the rest of commitMsgFile, or else the text
+// after summaryPrefix on the last line of its output. Both are empty when
+// the agent described nothing.
+func agentMessage(wtPath, finalLine string) (summary, body string) {
+	if data, err := os.ReadFile(filepath.Join(wtPath, commitMsgFile)); err == nil {
+		if msg := strings.TrimSpace(string(data)); msg != "" {
+			summary, body, _ = strings.Cut(msg, "\n")
+			return strings.TrimSpace(summary), strings.TrimSpace(body)
+		}
+	}
+	if rest, ok := strings.CutPrefix(finalLine, summaryPrefix); ok {
+		return strings.TrimSpace(rest), ""
+	}
+	return "", ""
+}
+
+// commitMessage renders a station's commit message template (RUN-36),
+// falling back to DefaultCommitMessage if it fails. The subject line always
+// carries the skip marker, so station commits never retrigger the line
+// (RUN-4, RUN-9).
+func commitMessage(tmpl string, vars prompt.CommitVars) string {
+	msg, err := prompt.RenderCommit(tmpl, vars)
+	if err != nil {
+		msg, _ = prompt.RenderCommit(config.DefaultCommitMessage, vars)
+	}
+	subject, body, _ := strings.Cut(strings.TrimSpace(msg), "\n")
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		subject = "assembly-line: station " + vars.Station
+	}
+	if !strings.Contains(subject, commitSkipMarker) {
+		subject += " " + commitSkipMarker
+	}
+	if body = strings.TrimSpace(body); body != "" {
+		return subject + "\n\n" + body
+	}
+	return subject
+}
+
+// stationCommitMessage returns the message for a station's commit: its
+// commit message template rendered with the agent's summary and body
+// (RUN-36), followed by its provenance trailers (TRC-1).
+func stationCommitMessage(tmpl string, trailers provenance.Trailers, summary, body string) string {
+	vars := prompt.CommitVars{
+		Station: trailers.Station,
+		Agent:   trailers.Agent,
+		Commit:  trailers.Source,
+		Summary: summary,
+		Body:    body,
+	}
+	return provenance.Append(commitMessage(tmpl, vars), trailers)
+}
+
+// lastLine returns the last non-blank line of text, trimmed.
+func lastLine(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
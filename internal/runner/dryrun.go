@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+)
+
+// printDryRun prints what a line run would do for each station — the resolved
+// command, args, full prompt, branch and worktree — without starting agents or
+// touching branches (RUN-22). force reports stations as running regardless of
+// their path filters (RUN-21).
+func printDryRun(dir string, cfg *config.Config, stations []config.Station, force bool) error {
+	baseDir, err := git.WorktreeBaseDir(dir)
+	if err != nil {
+		return fmt.Errorf("worktree base dir: %w", err)
+	}
+	head, _ := git.Run(dir, "rev-parse", "--short", cfg.Settings.Watches)
+	changed, filter := triggerFiles(dir, cfg)
+
+	fmt.Fprintf(os.Stdout, "assembly-line: dry run: %d station(s) would run on %s (%s)\n", len(stations), cfg.Settings.Watches, head)
+	for _, station := range stations {
+		resolved := cfg.ResolveStation(station)
+		branchName := git.StationBranchName(station.Name)
+		bases := basesOf(cfg, station)
+
+		branchNote := "exists"
+		if !git.BranchExists(dir, branchName) {
+			branchNote = "would be created from " + bases[0]
+		}
+		timeout := "none"
+		if resolved.Timeout > 0 {
+			timeout = resolved.Timeout.String()
+		}
+
+		prompt, err := renderPrompt(dir, cfg, station, resolved.Prompt, bases)
+		if err != nil {
+			return fmt.Errorf("station %s: rendering prompt: %w", station.Name, err)
+		}
+		// RUN-27: Show where the prompt goes, leaving placeholders in place
+		var suffix string
+		if !hasPlaceholder(resolved.Args) {
+			switch resolved.PromptVia {
+			case config.PromptViaFile:
+				suffix = " + prompt file"
+			case config.PromptViaStdin:
+				suffix = ", prompt on stdin"
+			default:
+				suffix = " + prompt"
+			}
+		}
+		quoted := make([]string, len(resolved.Args))
+		for i, a := range resolved.Args {
+			quoted[i] = fmt.Sprintf("%q", a)
+		}
+
+		fmt.Fprintf(os.Stdout, "\nstation %s\n", station.Name)
+		fmt.Fprintf(os.Stdout, "  branch:      %s (%s)\n", branchName, branchNote)
+		fmt.Fprintf(os.Stdout, "  predecessor: %s\n", strings.Join(bases, " + "))
+		fmt.Fprintf(os.Stdout, "  worktree:    %s\n", filepath.Join(baseDir, station.Name))
+		if station.Agent != "" {
+			fmt.Fprintf(os.Stdout, "  agent:       %s\n", station.Agent)
+		}
+		fmt.Fprintf(os.Stdout, "  command:     %s\n", resolved.Command)
+		if chain := cfg.AgentChain(station); len(chain) > 1 {
+			names := make([]string, len(chain)-1)
+			for i, c := range chain[1:] {
+				names[i] = c.Agent
+			}
+			fmt.Fprintf(os.Stdout, "  fallback:    %s\n", strings.Join(names, ", "))
+		}
+		fmt.Fprintf(os.Stdout, "  args:        [%s]%s\n", strings.Join(quoted, ", "), suffix)
+		if len(resolved.Env) > 0 {
+			fmt.Fprintf(os.Stdout, "  env:         %s\n", strings.Join(resolved.Env, " "))
+		}
+		fmt.Fprintf(os.Stdout, "  timeout:     %s\n", timeout)
+		fmt.Fprintf(os.Stdout, "  retries:     %d\n", resolved.Retries)
+		for _, command := range resolved.Verify {
+			fmt.Fprintf(os.Stdout, "  verify:      %s\n", command)
+		}
+		if resolved.Gate && len(cfg.Gates) > 0 {
+			names := make([]string, len(cfg.Gates))
+			for i, g := range cfg.Gates {
+				names[i] = g.Name
+			}
+			fmt.Fprintf(os.Stdout, "  gates:       %s\n", strings.Join(names, ", "))
+		}
+		if len(station.Paths) > 0 || len(station.PathsIgnore) > 0 {
+			// RUN-24: Report the path filter decision
+			decision := "would run (changed files match)"
+			if force {
+				decision = "would run (forced)"
+			} else if filter && !pathsMatch(station, changed) {
+				decision = "would be skipped (no changed files match)"
+			} else if !filter {
+				decision = "would run (changed files unknown)"
+			}
+			fmt.Fprintf(os.Stdout, "  paths:       %s\n", decision)
+		}
+		fmt.Fprintf(os.Stdout, "  prompt:\n")
+		for _, line := range strings.Split(fullPrompt(resolved.Preamble, prompt), "\n") {
+			fmt.Fprintf(os.Stdout, "    %s\n", line)
+		}
+	}
+	return nil
+}
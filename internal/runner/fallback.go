@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// errNoAgentAvailable is returned when every agent in a station's chain was
+// unavailable (RUN-28), wrapping the last agent's error so its exit code is
+// kept.
+var errNoAgentAvailable = errors.New("no agent available")
+
+// runAgentChain runs a station's agent in its worktree, trying each agent of
+// the chain in turn while agents are unavailable (RUN-28): not installed,
+// or exiting with one of their unavailable exit codes or stderr patterns.
+// The worktree is reset to baseRef before each fallback. Usage reported by
+// the agents is added to usage (RUN-29), and the last line of the final
+// agent's output is stored in finalLine (RUN-36). Returns the agent used and
+// its wait error; err reports problems running the chain itself.
+func runAgentChain(dir, wtPath, station string, chain []config.ResolvedStation, prompt, baseRef string, log io.Writer, usage *state.Usage, finalLine *string) (used config.ResolvedStation, agentErr error, err error) {
+	for i, candidate := range chain {
+		if i > 0 {
+			fmt.Fprintf(os.Stderr, "station %s: agent %s unavailable, falling back to %s\n", station, chain[i-1].Agent, candidate.Agent)
+			fmt.Fprintf(log, "assembly-line: agent %s unavailable, falling back to %s\n", chain[i-1].Agent, candidate.Agent)
+			if err := git.ResetHard(wtPath, baseRef); err != nil {
+				return candidate, nil, fmt.Errorf("resetting worktree for fallback: %w", err)
+			}
+			if err := git.CleanAll(wtPath); err != nil {
+				return candidate, nil, fmt.Errorf("cleaning worktree for fallback: %w", err)
+			}
+		}
+		used = candidate
+		_ = state.WriteStationAgent(dir, station, candidate.Agent)
+
+		agent, err := startAgent(wtPath, candidate, prompt, log)
+		if err != nil {
+			if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+				fmt.Fprintf(log, "assembly-line: %v\n", err)
+				agentErr = err
+				continue
+			}
+			return used, nil, err
+		}
+
+		// Write station PID file in main repo so status can detect the running agent
+		_ = state.WriteStationPID(dir, station, agent.pid(), time.Now())
+
+		// Wait for agent to complete, enforcing the station timeout (RUN-17)
+		agentErr = agent.wait(candidate.Timeout)
+
+		// Clean up station PID file
+		_ = state.RemoveStationPID(dir, station)
+
+		if u, ok := agent.usage(); ok {
+			usage.Add(u)
+		}
+		*finalLine = agent.finalLine()
+
+		if agentErr == nil || errors.Is(agentErr, errTimedOut) || !agent.unavailable(candidate, agentErr) {
+			return used, agentErr, nil
+		}
+	}
+	return used, fmt.Errorf("%w: %w", errNoAgentAvailable, agentErr), nil
+}
+
+// unavailable reports whether an agent's failure means the agent itself is
+// unavailable (e.g. rate-limited) rather than that it failed at the task.
+func (a *agentProcess) unavailable(resolved config.ResolvedStation, waitErr error) bool {
+	var exitErr *exec.ExitError
+	if errors.As(waitErr, &exitErr) && slices.Contains(resolved.UnavailableExitCodes, exitErr.ExitCode()) {
+		return true
+	}
+	for _, pattern := range resolved.UnavailablePatterns {
+		// Patterns are checked by Validate; invalid ones never match
+		if re, err := regexp.Compile(pattern); err == nil && re.Match(a.stderr.Bytes()) {
+			return true
+		}
+	}
+	return false
+}
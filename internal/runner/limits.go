@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/ignore"
+)
+
+// checkLimits checks the size of the agent's changes in a station's worktree
+// against the station's limits (RUN-34), returning an error describing every
+// limit exceeded.
+func checkLimits(wtPath string, limits config.Limits) error {
+	if limits.FilesChanged == nil && limits.LinesAdded == nil && limits.LinesRemoved == nil &&
+		limits.DeletedFiles == nil && len(limits.KeepFiles) == 0 {
+		return nil
+	}
+	changes, err := git.StagedChanges(wtPath)
+	if err != nil {
+		return fmt.Errorf("measuring changes: %w", err)
+	}
+	if violations := exceededLimits(limits, changes); len(violations) > 0 {
+		return fmt.Errorf("changes exceed limits: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+// exceededLimits describes each limit the changes exceed.
+func exceededLimits(limits config.Limits, changes []git.FileChange) []string {
+	var added, removed int
+	var deleted, kept []string
+	keep := ignore.New(limits.KeepFiles)
+	for _, c := range changes {
+		added += c.Added
+		removed += c.Removed
+		if c.Deleted {
+			deleted = append(deleted, c.Path)
+			if keep.Matches(c.Path) {
+				kept = append(kept, c.Path)
+			}
+		}
+	}
+
+	var violations []string
+	exceeds := func(limit *int, n int, what string) {
+		if limit != nil && n > *limit {
+			violations = append(violations, fmt.Sprintf("%d %s (limit %d)", n, what, *limit))
+		}
+	}
+	exceeds(limits.FilesChanged, len(changes), "files changed")
+	exceeds(limits.LinesAdded, added, "lines added")
+	exceeds(limits.LinesRemoved, removed, "lines removed")
+	exceeds(limits.DeletedFiles, len(deleted), "files deleted")
+	if len(kept) > 0 {
+		violations = append(violations, "deleted "+strings.Join(kept, ", "))
+	}
+	return violations
+}
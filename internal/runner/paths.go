@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/ignore"
+)
+
+// triggerFiles returns the files changed by the commit at the tip of the
+// watched branch. ok is false when they cannot be determined (e.g. the tip
+// is the root commit), in which case path filters are not applied.
+func triggerFiles(dir string, cfg *config.Config) (files []string, ok bool) {
+	files, err := git.DiffFiles(dir, cfg.Settings.Watches+"~1", cfg.Settings.Watches)
+	if err != nil {
+		return nil, false
+	}
+	return files, true
+}
+
+// pathsMatch reports whether a station's path filters select any of the
+// changed files (RUN-24): a file counts unless it matches paths_ignore, and,
+// when paths is set, only if it matches paths. Stations without filters
+// always match.
+func pathsMatch(station config.Station, files []string) bool {
+	if len(station.Paths) == 0 && len(station.PathsIgnore) == 0 {
+		return true
+	}
+	include, exclude := ignore.New(station.Paths), ignore.New(station.PathsIgnore)
+	for _, f := range files {
+		if exclude.Matches(f) {
+			continue
+		}
+		if len(station.Paths) == 0 || include.Matches(f) {
+			return true
+		}
+	}
+	return false
+}
@@ -11,3 +11,13 @@ import (
 func setProcGroup(cmd *exec.Cmd) {
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 }
+
+// terminateProcGroup asks the command's process group to exit (SIGTERM).
+func terminateProcGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+}
+
+// killProcGroup forcibly kills the command's process group (SIGKILL).
+func killProcGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -6,3 +6,13 @@ import "os/exec"
 
 // setProcGroup is a no-op on Windows; process groups are managed differently.
 func setProcGroup(_ *exec.Cmd) {}
+
+// terminateProcGroup kills the process directly; Windows has no SIGTERM.
+func terminateProcGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// killProcGroup kills the process directly.
+func killProcGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
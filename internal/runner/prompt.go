@@ -0,0 +1,28 @@
+package runner
+
+import (
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/prompt"
+)
+
+// renderPrompt renders a station's prompt template with the context of the
+// commit at the tip of the watched branch (RUN-25).
+func renderPrompt(dir string, cfg *config.Config, station config.Station, text string, bases []string) (string, error) {
+	watched := cfg.Settings.Watches
+	vars := prompt.Vars{
+		Predecessor: strings.Join(bases, ", "),
+		Station:     station.Name,
+	}
+	vars.Commit, _ = git.Run(dir, "rev-parse", watched)
+	vars.Message, _ = git.Run(dir, "show", "-s", "--format=%B", watched)
+	vars.Author, _ = git.Run(dir, "show", "-s", "--format=%an <%ae>", watched)
+	if files, _ := git.Run(dir, "show", "--format=", "--name-only", watched); files != "" {
+		vars.ChangedFiles = strings.Split(files, "\n")
+	}
+	vars.Diffstat, _ = git.Run(dir, "show", "--format=", "--stat", watched)
+	vars.Diff, _ = git.Run(dir, "show", "--format=", watched)
+	return prompt.Render(text, vars)
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// runRecord keeps a line run's history record up to date while it runs
+// (HIST-4): each station's record is saved as soon as it changes, so a run
+// stopped part-way (RUN-11) still shows what it did. It is safe for
+// concurrent use.
+type runRecord struct {
+	dir string
+
+	mu  sync.Mutex
+	run state.Run
+}
+
+// newRunRecord starts recording run.
+func newRunRecord(dir string, run state.Run) *runRecord {
+	r := &runRecord{dir: dir, run: run}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.save()
+	return r
+}
+
+// station records a station's result, replacing any earlier record of it.
+// The usage is copied, as a running station may still add to it.
+func (r *runRecord) station(rec state.StationRun) {
+	if rec.Usage != nil {
+		usage := *rec.Usage
+		rec.Usage = &usage
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.run.Stations {
+		if r.run.Stations[i].Name == rec.Name {
+			r.run.Stations[i] = rec
+			r.save()
+			return
+		}
+	}
+	r.run.Stations = append(r.run.Stations, rec)
+	r.save()
+}
+
+// save writes the record of the run in progress. The caller holds r.mu.
+func (r *runRecord) save() {
+	r.run.End = time.Now().UTC()
+	if err := state.WriteRunInProgress(r.dir, r.run); err != nil {
+		fmt.Fprintf(os.Stderr, "assembly-line: warning: could not record run progress: %v\n", err)
+	}
+}
+
+// finish appends the finished run to the history ledger (HIST-1), with its
+// stations in config order regardless of completion order.
+func (r *runRecord) finish(stations []config.Station) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ordered []state.StationRun
+	for _, station := range stations {
+		for _, rec := range r.run.Stations {
+			if rec.Name == station.Name {
+				ordered = append(ordered, rec)
+			}
+		}
+	}
+	r.run.Stations = ordered
+	r.run.End = time.Now().UTC()
+	if err := state.AppendHistory(r.dir, r.run); err != nil {
+		return err
+	}
+	return state.RemoveRunInProgress(r.dir, r.run.ID)
+}
@@ -1,9 +1,12 @@
 package runner
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/re-cinq/assembly-line/internal/config"
 	"github.com/re-cinq/assembly-line/internal/git"
@@ -18,8 +21,14 @@ const commitSkipMarker = "[skip line]"
 // SkipMarkers are commit message markers that prevent retriggering.
 var SkipMarkers = []string{"[skip ci]", "[ci skip]", commitSkipMarker, "[line skip]"}
 
-// Run executes the full assembly line pipeline.
-func Run(dir string, cfg *config.Config) error {
+// newRunID returns an identifier for a line run. IDs sort chronologically.
+func newRunID() string {
+	return time.Now().UTC().Format("20060102-150405.000")
+}
+
+// Run executes the assembly line pipeline: every station, or the subset
+// selected by opts.
+func Run(dir string, cfg *config.Config, opts Options) error {
 	// RUN-4 layer 2: Check env var guard
 	if os.Getenv("LINE_RUNNING") == "1" {
 		fmt.Fprintln(os.Stderr, "assembly-line: skipping (LINE_RUNNING=1)")
@@ -36,31 +45,47 @@ func Run(dir string, cfg *config.Config) error {
 		return nil
 	}
 
-	// RUN-9: Check if the last commit message contains a skip marker
-	lastMsg, err := git.LastCommitMessage(dir)
+	// RUN-20: Select the stations to run
+	stations, err := selectStations(dir, cfg, opts)
 	if err != nil {
-		return fmt.Errorf("getting last commit message: %w", err)
-	}
-	for _, marker := range SkipMarkers {
-		if strings.Contains(lastMsg, marker) {
-			fmt.Fprintf(os.Stderr, "assembly-line: skipping (commit contains %s)\n", marker)
-			return nil
-		}
+		return err
 	}
 
-	// RUN-7, RUN-8: Check .lineignore
-	parentRef := "HEAD~1"
-	changedFiles, _ := git.DiffFiles(dir, parentRef, "HEAD")
-	if len(changedFiles) > 0 {
-		matcher, err := ignore.Load(dir)
+	// RUN-21: --force bypasses the skip-marker and .lineignore checks (and
+	// the stations' path filters, RUN-24)
+	if !opts.Force {
+		// RUN-9: Check if the last commit message contains a skip marker
+		lastMsg, err := git.LastCommitMessage(dir)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "assembly-line: warning: could not load .lineignore: %v\n", err)
-		} else if matcher.AllIgnored(changedFiles) {
-			fmt.Fprintln(os.Stderr, "assembly-line: skipping (all changed files are ignored)")
-			return nil
+			return fmt.Errorf("getting last commit message: %w", err)
+		}
+		for _, marker := range SkipMarkers {
+			if strings.Contains(lastMsg, marker) {
+				fmt.Fprintf(os.Stderr, "assembly-line: skipping (commit contains %s)\n", marker)
+				return nil
+			}
+		}
+
+		// RUN-7, RUN-8: Check .lineignore
+		parentRef := "HEAD~1"
+		changedFiles, _ := git.DiffFiles(dir, parentRef, "HEAD")
+		if len(changedFiles) > 0 {
+			matcher, err := ignore.Load(dir)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "assembly-line: warning: could not load .lineignore: %v\n", err)
+			} else if matcher.AllIgnored(changedFiles) {
+				fmt.Fprintln(os.Stderr, "assembly-line: skipping (all changed files are ignored)")
+				return nil
+			}
 		}
 	}
 
+	// RUN-22: Report what would run, leaving agents, branches and any
+	// in-progress run untouched
+	if opts.DryRun {
+		return printDryRun(dir, cfg, stations, opts.Force)
+	}
+
 	// RUN-11: Check for existing runner and terminate it
 	existingPID, err := state.ReadPID(dir)
 	if err != nil {
@@ -76,6 +101,61 @@ func Run(dir string, cfg *config.Config) error {
 		}
 	}
 
+	return runStations(dir, cfg, stations, opts.Force)
+}
+
+// Retry re-runs the line starting from the named station (RTY-2), or from the
+// first failed station when from is empty (RTY-1). Earlier stations are not
+// re-run; their existing branches are reused as predecessors. Refuses to start
+// while another line run holds the runner PID lock (RTY-3).
+func Retry(dir string, cfg *config.Config, from string) error {
+	if os.Getenv("LINE_RUNNING") == "1" {
+		fmt.Fprintln(os.Stderr, "assembly-line: skipping (LINE_RUNNING=1)")
+		return nil
+	}
+
+	start := -1
+	for i, station := range cfg.Stations {
+		if from != "" && station.Name == from {
+			start = i
+			break
+		}
+		if _, failed := state.ReadStationFailure(dir, station.Name); from == "" && failed {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		if from != "" {
+			return fmt.Errorf("unknown station %q", from)
+		}
+		return fmt.Errorf("no failed station to retry")
+	}
+
+	stations, err := selectStations(dir, cfg, Options{From: cfg.Stations[start].Name})
+	if err != nil {
+		return err
+	}
+
+	existingPID, err := state.ReadPID(dir)
+	if err != nil {
+		return fmt.Errorf("reading PID: %w", err)
+	}
+	if existingPID > 0 && state.IsProcessRunning(existingPID) {
+		return fmt.Errorf("a line run is already in progress (PID %d)", existingPID)
+	}
+
+	_ = state.RemoveStationFailed(dir, cfg.Stations[start].Name)
+	fmt.Fprintf(os.Stderr, "assembly-line: retrying from station %s\n", cfg.Stations[start].Name)
+	return runStations(dir, cfg, stations, false)
+}
+
+// runStations executes stations as a dependency graph (RUN-23): each station
+// starts once the stations it needs have succeeded, so independent stations
+// run concurrently. It holds the runner PID lock for the duration and records
+// the run in the history ledger. force runs stations regardless of their path
+// filters (RUN-21).
+func runStations(dir string, cfg *config.Config, stations []config.Station, force bool) error {
 	// Write our PID
 	if err := state.WritePID(dir, os.Getpid()); err != nil {
 		return fmt.Errorf("writing PID: %w", err)
@@ -95,16 +175,127 @@ func Run(dir string, cfg *config.Config) error {
 	}
 	_ = git.PruneWorktrees(dir)
 
-	// RUN-1: Execute stations in sequence
-	// The chain: watched_branch -> station1 -> station2 -> ... -> stationN
-	predecessor := cfg.Settings.Watches
-	for _, station := range cfg.Stations {
-		fmt.Fprintf(os.Stderr, "assembly-line: running station %s\n", station.Name)
-		if err := runStation(dir, cfg, station, predecessor); err != nil {
-			fmt.Fprintf(os.Stderr, "assembly-line: station %s failed: %v\n", station.Name, err)
-			break
+	// HIST-4: Record runs stopped before they finished (RUN-11), then keep
+	// this run's record up to date as it goes; HIST-1: it joins the history
+	// ledger when it finishes
+	if err := state.FlushInterruptedRuns(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "assembly-line: warning: could not record interrupted runs: %v\n", err)
+	}
+	run := state.Run{ID: newRunID(), Start: time.Now().UTC()}
+	run.Commit, _ = git.Run(dir, "rev-parse", cfg.Settings.Watches)
+	record := newRunRecord(dir, run)
+
+	// RUN-30: Stop starting stations once the budget is exhausted. Stations
+	// this run starts are no longer held back by an earlier run's budget.
+	_ = state.RemoveBudgetExceeded(dir)
+	for _, station := range stations {
+		if reason, _ := state.ReadStationFailure(dir, station.Name); reason == state.FailReasonBudget {
+			_ = state.RemoveStationFailed(dir, station.Name)
 		}
-		predecessor = git.StationBranchName(station.Name)
+	}
+	spent := newBudget(dir, cfg.Settings.Budget, run.Start)
+	if reason := spent.exhaustedReason(); reason != "" {
+		_ = state.WriteBudgetExceeded(dir, reason)
+	}
+
+	// RUN-24: Stations whose path filters don't match the triggering commit
+	// are skipped, unless forced
+	changed, filter := triggerFiles(dir, cfg)
+	filter = filter && !force
+
+	// RUN-1, RUN-23: Each station waits for the selected stations it needs.
+	// Stations outside the selection are not waited for; their existing
+	// branches are used as-is.
+	done := make(map[string]chan struct{}, len(stations))
+	for _, station := range stations {
+		done[station.Name] = make(chan struct{})
+	}
+	var (
+		mu     sync.Mutex
+		failed = make(map[string]bool)
+		wg     sync.WaitGroup
+	)
+	for _, station := range stations {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[station.Name])
+
+			for _, need := range cfg.Needs(station) {
+				if ch, selected := done[need]; selected {
+					<-ch
+				}
+			}
+			if reason := spent.exhaustedReason(); reason != "" {
+				fmt.Fprintf(os.Stderr, "assembly-line: not running station %s (%s)\n", station.Name, reason)
+				_ = state.WriteStationFailed(dir, station.Name, state.FailReasonBudget)
+				mu.Lock()
+				failed[station.Name] = true
+				mu.Unlock()
+				return
+			}
+			for _, need := range cfg.Needs(station) {
+				mu.Lock()
+				needFailed := failed[need]
+				mu.Unlock()
+				if needFailed {
+					// A station never runs on top of a failed one (RUN-14)
+					fmt.Fprintf(os.Stderr, "assembly-line: not running station %s (needs %s, which did not succeed)\n", station.Name, need)
+					mu.Lock()
+					failed[station.Name] = true
+					mu.Unlock()
+					return
+				}
+			}
+
+			skip := filter && !pathsMatch(station, changed)
+			if skip {
+				fmt.Fprintf(os.Stderr, "assembly-line: skipping station %s (no changed files match its paths)\n", station.Name)
+			} else {
+				fmt.Fprintf(os.Stderr, "assembly-line: running station %s\n", station.Name)
+			}
+			// Until it finishes, the station counts as interrupted should
+			// the run be stopped, keeping what its agents have spent so far
+			// for the day budget (RUN-30)
+			started := time.Now()
+			progress := func(rec state.StationRun) {
+				rec.Name = station.Name
+				rec.Outcome = state.OutcomeInterrupted
+				rec.DurationMS = time.Since(started).Milliseconds()
+				record.station(rec)
+			}
+			progress(state.StationRun{ExitCode: -1})
+			rec, err := runStation(dir, cfg, station, basesOf(cfg, station), run.ID, run.Commit, skip, progress)
+			rec.Name = station.Name
+			rec.DurationMS = time.Since(started).Milliseconds()
+			switch {
+			case errors.Is(err, errSkipped):
+				rec.Outcome = state.OutcomeSkipped
+				err = nil
+			case errors.Is(err, errTimedOut):
+				rec.Outcome = state.OutcomeTimedOut
+			case err != nil:
+				rec.Outcome = state.OutcomeFailed
+			default:
+				rec.Outcome = state.OutcomeSucceeded
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "assembly-line: station %s failed: %v\n", station.Name, err)
+			}
+			if reason := spent.add(rec.Usage); reason != "" {
+				_ = state.WriteBudgetExceeded(dir, reason)
+			}
+			record.station(rec)
+
+			mu.Lock()
+			defer mu.Unlock()
+			failed[station.Name] = err != nil
+		}()
+	}
+	wg.Wait()
+
+	if err := record.finish(stations); err != nil {
+		fmt.Fprintf(os.Stderr, "assembly-line: warning: could not record run history: %v\n", err)
 	}
 
 	return nil
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/ignore"
+)
+
+// checkScope checks the files changed in a station's worktree against the
+// station's allowed and forbidden paths (RUN-33), returning those outside
+// them for the caller to record for line status. They are logged; with the
+// revert policy they are restored, otherwise an error fails the attempt.
+func checkScope(wtPath string, station config.Station, log io.Writer) ([]string, error) {
+	if len(station.AllowedPaths) == 0 && len(station.ForbiddenPaths) == 0 {
+		return nil, nil
+	}
+	changed, err := git.ChangedFiles(wtPath)
+	if err != nil {
+		return nil, fmt.Errorf("listing changed files: %w", err)
+	}
+	outside := outOfScope(station, changed)
+	if len(outside) == 0 {
+		return nil, nil
+	}
+
+	if station.PathPolicy == config.PathPolicyRevert {
+		fmt.Fprintf(os.Stderr, "station %s: reverting changes outside allowed paths: %s\n", station.Name, strings.Join(outside, ", "))
+		fmt.Fprintf(log, "assembly-line: reverting changes outside allowed paths: %s\n", strings.Join(outside, ", "))
+		if err := git.RevertFiles(wtPath, outside); err != nil {
+			return outside, fmt.Errorf("reverting changes outside allowed paths: %w", err)
+		}
+		return outside, nil
+	}
+	return outside, fmt.Errorf("changed files outside allowed paths: %s", strings.Join(outside, ", "))
+}
+
+// outOfScope returns the files a station may not change: those not matching
+// allowed_paths (when set) and those matching forbidden_paths.
+func outOfScope(station config.Station, files []string) []string {
+	allowed, forbidden := ignore.New(station.AllowedPaths), ignore.New(station.ForbiddenPaths)
+	var outside []string
+	for _, f := range files {
+		if (len(station.AllowedPaths) > 0 && !allowed.Matches(f)) || forbidden.Matches(f) {
+			outside = append(outside, f)
+		}
+	}
+	return outside
+}
@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/secrets"
+)
+
+// SecretScanner returns the secret scanner configured by settings.secrets
+// (RUN-35).
+func SecretScanner(cfg *config.Config) (*secrets.Scanner, error) {
+	rules := make([]secrets.Rule, len(cfg.Settings.Secrets.Rules))
+	for i, r := range cfg.Settings.Secrets.Rules {
+		rules[i] = secrets.Rule{Name: r.Name, Pattern: r.Pattern, MinEntropy: r.Entropy}
+	}
+	return secrets.New(rules, cfg.Settings.Secrets.Allow)
+}
+
+// ScanStaged scans the changes staged in dir for secrets, returning an error
+// listing the findings (RUN-35).
+func ScanStaged(dir string, cfg *config.Config) error {
+	scanner, err := SecretScanner(cfg)
+	if err != nil {
+		return err
+	}
+	diff, err := git.StagedDiff(dir)
+	if err != nil {
+		return fmt.Errorf("reading staged changes: %w", err)
+	}
+	findings := scanner.ScanDiff(diff)
+	if len(findings) == 0 {
+		return nil
+	}
+	described := make([]string, len(findings))
+	for i, f := range findings {
+		described[i] = f.String()
+	}
+	return fmt.Errorf("secrets found: %s", strings.Join(described, "; "))
+}
+
+// checkSecrets scans all of the agent's changes in a station's worktree for
+// secrets before they are committed (RUN-35).
+func checkSecrets(wtPath string, cfg *config.Config) error {
+	if !cfg.Settings.Secrets.ScanStations() {
+		return nil
+	}
+	if err := git.StageAll(wtPath); err != nil {
+		return err
+	}
+	return ScanStaged(wtPath, cfg)
+}
@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/git"
+)
+
+// Options controls which stations a line run executes and which trigger
+// checks it applies. The zero value runs every station.
+type Options struct {
+	Stations []string // run only these stations (RUN-20); empty means all
+	From     string   // first station to run (RUN-20)
+	To       string   // last station to run (RUN-20)
+	Force    bool     // bypass skip-marker and .lineignore checks (RUN-21)
+	DryRun   bool     // print what would run without running it (RUN-22)
+}
+
+// stationIndex returns the index of the named station in cfg, or -1.
+func stationIndex(cfg *config.Config, name string) int {
+	for i, s := range cfg.Stations {
+		if s.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// basesOf returns the branches a station builds on: the branches of the
+// stations it needs (RUN-23), or the watched branch if it needs none.
+func basesOf(cfg *config.Config, station config.Station) []string {
+	needs := cfg.Needs(station)
+	if len(needs) == 0 {
+		return []string{cfg.Settings.Watches}
+	}
+	bases := make([]string, len(needs))
+	for i, name := range needs {
+		bases[i] = git.StationBranchName(name)
+	}
+	return bases
+}
+
+// selectStations returns the stations to run for opts, in config order.
+// Stations that need a station which is not itself selected must find that
+// station's branch already in place to build on.
+func selectStations(dir string, cfg *config.Config, opts Options) ([]config.Station, error) {
+	if len(cfg.Stations) == 0 {
+		return nil, nil
+	}
+	from, to := 0, len(cfg.Stations)-1
+	for _, name := range append(slices.Clone(opts.Stations), opts.From, opts.To) {
+		if name != "" && stationIndex(cfg, name) == -1 {
+			return nil, fmt.Errorf("unknown station %q", name)
+		}
+	}
+	if opts.From != "" {
+		from = stationIndex(cfg, opts.From)
+	}
+	if opts.To != "" {
+		to = stationIndex(cfg, opts.To)
+	}
+	if from > to {
+		return nil, fmt.Errorf("station %q comes after station %q", opts.From, opts.To)
+	}
+
+	var selected []config.Station
+	for i := from; i <= to; i++ {
+		station := cfg.Stations[i]
+		if len(opts.Stations) > 0 && !slices.Contains(opts.Stations, station.Name) {
+			continue
+		}
+		for _, need := range cfg.Needs(station) {
+			if slices.ContainsFunc(selected, func(s config.Station) bool { return s.Name == need }) {
+				continue
+			}
+			if branch := git.StationBranchName(need); !git.BranchExists(dir, branch) {
+				return nil, fmt.Errorf("station %s builds on %s, which does not exist; run station %s first", station.Name, branch, need)
+			}
+		}
+		selected = append(selected, station)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no stations selected")
+	}
+	return selected, nil
+}
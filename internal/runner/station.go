@@ -1,33 +1,53 @@
 package runner
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/re-cinq/assembly-line/internal/config"
 	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/provenance"
 	"github.com/re-cinq/assembly-line/internal/state"
 )
 
 // runStation executes a single station in an ephemeral git worktree (RUN-15).
-// The user's working tree is never disturbed.
-func runStation(dir string, cfg *config.Config, station config.Station, predecessor string) error {
+// The user's working tree is never disturbed. The returned record carries the
+// agent's exit code and the resulting station commit (HIST-1); the caller
+// fills in the name, outcome and duration. bases are the branches the station
+// builds on (RUN-23); the first is its predecessor. source is the watched
+// branch commit that triggered the run (TRC-1). A skipped station (RUN-24)
+// only carries its branch forward onto its predecessor and returns errSkipped.
+// progress is called with the record so far after each agent attempt, so that
+// what the station has spent is kept should the run be stopped (HIST-4).
+func runStation(dir string, cfg *config.Config, station config.Station, bases []string, runID, source string, skip bool, progress func(state.StationRun)) (state.StationRun, error) {
+	rec := state.StationRun{ExitCode: -1}
 	resolved := cfg.ResolveStation(station)
 	branchName := git.StationBranchName(station.Name)
 
+	// Stations may run concurrently (RUN-23); serialise changes to the main
+	// repo's branches and worktree bookkeeping.
+	repoMu.Lock()
+	unlock := sync.OnceFunc(repoMu.Unlock)
+	defer unlock()
+
 	// Create branch if it doesn't exist (RUN-6: catch up)
 	if !git.BranchExists(dir, branchName) {
-		if err := git.CreateBranch(dir, branchName, predecessor); err != nil {
-			return fmt.Errorf("creating branch %s: %w", branchName, err)
+		if err := git.CreateBranch(dir, branchName, bases[0]); err != nil {
+			return rec, fmt.Errorf("creating branch %s: %w", branchName, err)
 		}
 	}
 
 	// Compute worktree path (RUN-15)
 	baseDir, err := git.WorktreeBaseDir(dir)
 	if err != nil {
-		return fmt.Errorf("station %s: worktree base dir: %w", station.Name, err)
+		return rec, fmt.Errorf("station %s: worktree base dir: %w", station.Name, err)
 	}
 	wtPath := filepath.Join(baseDir, station.Name)
 
@@ -37,54 +57,269 @@ func runStation(dir string, cfg *config.Config, station config.Station, predeces
 
 	// Create the worktree
 	if err := os.MkdirAll(baseDir, 0o755); err != nil {
-		return fmt.Errorf("station %s: creating worktree base dir: %w", station.Name, err)
+		return rec, fmt.Errorf("station %s: creating worktree base dir: %w", station.Name, err)
 	}
 	if err := git.AddWorktree(dir, wtPath, branchName); err != nil {
-		return fmt.Errorf("station %s: adding worktree: %w", station.Name, err)
+		return rec, fmt.Errorf("station %s: adding worktree: %w", station.Name, err)
 	}
+	unlock()
 	defer func() {
+		repoMu.Lock()
+		defer repoMu.Unlock()
 		_ = git.RemoveWorktree(dir, wtPath)
 		_ = os.RemoveAll(wtPath)
 	}()
 
+	// LOG-1: Capture the station's output in a per-run log file, from the
+	// start so that failures before the agent runs are in it too
+	logFile, err := state.CreateStationLog(dir, station.Name, runID)
+	if err != nil {
+		return rec, fmt.Errorf("station %s: creating log: %w", station.Name, err)
+	}
+	defer logFile.Close()
+
+	// LOG-4: Mark the station as running for the whole run, so its log is
+	// followed through retries and checks, not just while an agent runs
+	_ = state.WriteStationRunning(dir, station.Name, os.Getpid())
+	defer func() { _ = state.RemoveStationRunning(dir, station.Name) }()
+
+	// RUN-23: A station needing several stations builds on their combined
+	// changes; they must not conflict
+	predecessor := bases[0]
+	if len(bases) > 1 {
+		combined, err := combineBases(wtPath, branchName, bases)
+		if err != nil {
+			fmt.Fprintf(logFile, "assembly-line: %v\n", err)
+			_ = state.WriteStationFailed(dir, station.Name, state.FailReasonFailed)
+			return rec, fmt.Errorf("station %s: %w", station.Name, err)
+		}
+		predecessor = combined
+	}
+
 	// Rebase onto predecessor to pick up changes (in the worktree)
 	if err := git.Rebase(wtPath, predecessor); err != nil {
 		// RUN-6: If rebase fails, reset to predecessor and try again
 		fmt.Fprintf(os.Stderr, "station %s: rebase conflict, resetting to %s\n", station.Name, predecessor)
+		fmt.Fprintf(logFile, "assembly-line: rebase conflict, resetting to %s\n", predecessor)
 		_ = git.RebaseAbort(wtPath)
 		if err := git.ResetHard(wtPath, predecessor); err != nil {
-			return fmt.Errorf("station %s: reset failed: %w", station.Name, err)
+			return rec, fmt.Errorf("station %s: reset failed: %w", station.Name, err)
 		}
 	}
 
-	// Run the agent in the worktree (RUN-1, RUN-12)
-	agent, err := startAgent(wtPath, resolved.Command, resolved.Args, resolved.Prompt)
+	// RUN-24: A skipped station passes its predecessor's changes through
+	// without running its agent, so stations building on it stay current
+	if skip {
+		fmt.Fprintln(logFile, "assembly-line: skipped (no changed files match its paths)")
+		_ = state.RemoveStationFailed(dir, station.Name)
+		_ = state.WriteStationSkipped(dir, station.Name)
+		rec.ExitCode = 0
+		rec.Commit, _ = git.Run(wtPath, "rev-parse", "HEAD")
+		return rec, errSkipped
+	}
+	_ = state.RemoveStationSkipped(dir, station.Name)
+	_ = state.WriteStationOutOfScope(dir, station.Name, nil)
+
+	// RUN-25: Render the prompt template with the triggering commit's context
+	prompt, err := renderPrompt(dir, cfg, station, resolved.Prompt, bases)
+	if err != nil {
+		fmt.Fprintf(logFile, "assembly-line: rendering prompt: %v\n", err)
+		_ = state.WriteStationFailed(dir, station.Name, state.FailReasonFailed)
+		return rec, fmt.Errorf("station %s: rendering prompt: %w", station.Name, err)
+	}
+
+	// RUN-18: Remember the pre-agent state so each retry starts from scratch
+	baseRef, err := git.Run(wtPath, "rev-parse", "HEAD")
 	if err != nil {
-		return fmt.Errorf("station %s: %w", station.Name, err)
+		return rec, fmt.Errorf("station %s: %w", station.Name, err)
 	}
 
-	// Write station PID file in main repo so status can detect the running agent
-	_ = state.WriteStationPID(dir, station.Name, agent.pid(), time.Now())
+	attempts := resolved.Retries + 1
+	chain := cfg.AgentChain(station)
+	var (
+		used      config.ResolvedStation
+		agentErr  error
+		checkErr  error
+		usage     state.Usage
+		finalLine string
+	)
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			backoff := retryBackoff(resolved.RetryBackoff, attempt)
+			fmt.Fprintf(os.Stderr, "station %s: retrying in %s (attempt %d/%d)\n", station.Name, backoff, attempt, attempts)
+			fmt.Fprintf(logFile, "assembly-line: retrying in %s (attempt %d/%d)\n", backoff, attempt, attempts)
+			time.Sleep(backoff)
+			if err := git.ResetHard(wtPath, baseRef); err != nil {
+				return rec, stationError(dir, station.Name, logFile, fmt.Errorf("resetting worktree for retry: %w", err))
+			}
+			if err := git.CleanAll(wtPath); err != nil {
+				return rec, stationError(dir, station.Name, logFile, fmt.Errorf("cleaning worktree for retry: %w", err))
+			}
+		}
+		_ = state.WriteStationAttempt(dir, station.Name, attempt, attempts)
+		rec.Attempts = attempt
+
+		// Run the agent in the worktree (RUN-1, RUN-12), falling back while
+		// agents are unavailable (RUN-28)
+		used, agentErr, err = runAgentChain(dir, wtPath, station.Name, chain, fullPrompt(resolved.Preamble, prompt), baseRef, logFile, &usage, &finalLine)
+		if err != nil {
+			return rec, stationError(dir, station.Name, logFile, err)
+		}
+		rec.Agent = used.Agent
+		if usage != (state.Usage{}) {
+			rec.Usage = &usage
+		}
+		rec.ExitCode = exitCode(agentErr)
+		progress(rec)
+
+		// RUN-33, RUN-34, RUN-31, RUN-32, RUN-35: The agent's changes must
+		// stay within the station's allowed paths and limits, pass
+		// verification and, if enabled, the gates, and contain no secrets
+		var outside []string
+		checkTree := func() error {
+			files, err := checkScope(wtPath, station, logFile)
+			outside = append(outside, files...)
+			if err != nil {
+				return err
+			}
+			return checkLimits(wtPath, station.Limits)
+		}
+		checkErr = nil
+		if agentErr == nil {
+			checkErr = checkTree()
+		}
+		if agentErr == nil && checkErr == nil {
+			checkErr = runVerify(wtPath, resolved.Verify, logFile)
+		}
+		if agentErr == nil && checkErr == nil && resolved.Gate {
+			checkErr = runGates(wtPath, cfg.Gates, logFile)
+		}
+		// RUN-31: Verification may leave files behind (build output, coverage
+		// profiles, formatting fixes), so the tree that would be committed is
+		// checked again
+		if agentErr == nil && checkErr == nil {
+			checkErr = checkTree()
+		}
+		if agentErr == nil && checkErr == nil {
+			checkErr = checkSecrets(wtPath, cfg)
+		}
+		_ = state.WriteStationOutOfScope(dir, station.Name, outside)
+		if agentErr == nil && checkErr == nil {
+			break
+		}
+		switch {
+		case checkErr != nil:
+			fmt.Fprintf(os.Stderr, "station %s: %v\n", station.Name, checkErr)
+			fmt.Fprintf(logFile, "assembly-line: %v\n", checkErr)
+		case errors.Is(agentErr, errTimedOut):
+			fmt.Fprintf(os.Stderr, "station %s: agent timed out after %s\n", station.Name, used.Timeout)
+			fmt.Fprintf(logFile, "assembly-line: agent timed out after %s\n", used.Timeout)
+		default:
+			fmt.Fprintf(os.Stderr, "station %s: agent exited with error: %v\n", station.Name, agentErr)
+			fmt.Fprintf(logFile, "assembly-line: agent exited with error: %v\n", agentErr)
+		}
 
-	// Wait for agent to complete
-	agentErr := agent.wait()
+		// RUN-30: Don't retry once the station has spent its budget
+		if reason := exhausted("station", cfg.Settings.Budget.Station, usage); reason != "" && attempt < attempts {
+			fmt.Fprintf(os.Stderr, "station %s: not retrying (%s)\n", station.Name, reason)
+			fmt.Fprintf(logFile, "assembly-line: not retrying (%s)\n", reason)
+			break
+		}
+	}
 
-	// Clean up station PID file
-	_ = state.RemoveStationPID(dir, station.Name)
+	// RUN-17: A timed-out station is failed with a distinct reason
+	if errors.Is(agentErr, errTimedOut) {
+		_ = state.WriteStationFailed(dir, station.Name, state.FailReasonTimedOut)
+		return rec, fmt.Errorf("agent %w after %s", agentErr, used.Timeout)
+	}
 
 	// RUN-14: A failed station blocks the line and is reported as 'failed'
 	if agentErr != nil {
-		fmt.Fprintf(os.Stderr, "station %s: agent exited with error: %v\n", station.Name, agentErr)
-		_ = state.WriteStationFailed(dir, station.Name)
-		return fmt.Errorf("agent failed: %w", agentErr)
+		_ = state.WriteStationFailed(dir, station.Name, state.FailReasonFailed)
+		return rec, fmt.Errorf("agent failed: %w", agentErr)
+	}
+
+	// TRC-1: Station commits record what produced them
+	trailers := provenance.Trailers{Station: station.Name, Source: source, RunID: runID, Agent: used.Agent}
+
+	// RUN-31: Changes that fail their checks never reach the station branch
+	if checkErr != nil {
+		if err := rejectChanges(wtPath, station.Name, baseRef, resolved.Quarantine, trailers, logFile); err != nil {
+			fmt.Fprintf(os.Stderr, "station %s: discarding changes: %v\n", station.Name, err)
+		}
+		_ = state.WriteStationFailed(dir, station.Name, state.FailReasonFailed)
+		return rec, checkErr
 	}
 	_ = state.RemoveStationFailed(dir, station.Name)
 
-	// RUN-5: Commit any changes with skip marker (RUN-4, RUN-9)
-	commitMsg := fmt.Sprintf("assembly-line: station %s %s", station.Name, commitSkipMarker)
+	// RUN-5: Commit any changes with skip marker (RUN-4, RUN-9), described
+	// by the agent (RUN-36)
+	summary, body := agentMessage(wtPath, finalLine)
+	commitMsg := stationCommitMessage(resolved.CommitMessage, trailers, summary, body)
 	if err := git.CommitAll(wtPath, commitMsg); err != nil {
 		fmt.Fprintf(os.Stderr, "station %s: commit failed: %v\n", station.Name, err)
 	}
+	rec.Commit, _ = git.Run(wtPath, "rev-parse", "HEAD")
+	if rec.Commit != baseRef {
+		rec.Summary = summary
+	}
+
+	return rec, nil
+}
+
+// stationError logs an error that stops a station from running its agent and
+// marks the station failed (RUN-14), returning the error for the caller.
+func stationError(dir, station string, log io.Writer, err error) error {
+	fmt.Fprintf(log, "assembly-line: %v\n", err)
+	_ = state.WriteStationFailed(dir, station, state.FailReasonFailed)
+	return fmt.Errorf("station %s: %w", station, err)
+}
 
-	return nil
+// maxRetryBackoff caps the doubling delay between retries (RUN-18), however
+// many retries are configured.
+const maxRetryBackoff = 10 * time.Minute
+
+// retryBackoff returns the delay before the given attempt (2 or later): base,
+// doubled for each further attempt up to maxRetryBackoff. A base above the
+// cap is used as is.
+func retryBackoff(base time.Duration, attempt int) time.Duration {
+	backoff := base
+	for i := 2; i < attempt && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	return max(base, min(backoff, maxRetryBackoff))
+}
+
+// errSkipped is returned by runStation for a station whose path filters did
+// not match the triggering commit (RUN-24).
+var errSkipped = errors.New("skipped")
+
+// repoMu serialises operations that modify the main repository's branches and
+// worktree bookkeeping while stations run concurrently (RUN-23).
+var repoMu sync.Mutex
+
+// combineBases combines the changes of several base branches into a single
+// commit, working in the station's worktree: each further base's commits are
+// rebased onto the combination so far, keeping history linear (RUN-16).
+// Returns the combined commit, leaving the worktree back on branch. Fails if
+// the bases conflict.
+func combineBases(wtPath, branch string, bases []string) (string, error) {
+	combined, err := git.Run(wtPath, "rev-parse", bases[0])
+	if err != nil {
+		return "", err
+	}
+	for _, base := range bases[1:] {
+		if err := git.CheckoutDetached(wtPath, base); err != nil {
+			return "", err
+		}
+		if err := git.Rebase(wtPath, combined); err != nil {
+			_ = git.RebaseAbort(wtPath)
+			_ = git.Checkout(wtPath, branch)
+			return "", fmt.Errorf("cannot combine %s: %s conflicts with %s", strings.Join(bases, ", "), base, strings.Join(bases[:slices.Index(bases, base)], ", "))
+		}
+		if combined, err = git.Run(wtPath, "rev-parse", "HEAD"); err != nil {
+			return "", err
+		}
+	}
+	return combined, git.Checkout(wtPath, branch)
 }
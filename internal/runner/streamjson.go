@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/re-cinq/assembly-line/internal/state"
+)
+
+// streamJSONParser is an io.Writer that parses Claude Code's
+// --output-format stream-json output as the agent writes it (RUN-29). Each
+// line is a JSON event; the final "result" event carries the cost, token
+// usage, number of turns and result text. Other lines are ignored.
+type streamJSONParser struct {
+	partial []byte
+	usage   state.Usage
+	parsed  bool // a result event was seen
+}
+
+// resultEvent is the subset of Claude Code's result event that is recorded.
+type resultEvent struct {
+	Type         string  `json:"type"`
+	Subtype      string  `json:"subtype"`
+	IsError      bool    `json:"is_error"`
+	NumTurns     int     `json:"num_turns"`
+	Result       string  `json:"result"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+	Usage        struct {
+		InputTokens              int64 `json:"input_tokens"`
+		OutputTokens             int64 `json:"output_tokens"`
+		CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+		CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	} `json:"usage"`
+}
+
+func (p *streamJSONParser) Write(b []byte) (int, error) {
+	p.partial = append(p.partial, b...)
+	for {
+		i := bytes.IndexByte(p.partial, '\n')
+		if i < 0 {
+			break
+		}
+		p.parseLine(p.partial[:i])
+		p.partial = p.partial[i+1:]
+	}
+	return len(b), nil
+}
+
+// parseLine records the usage from a result event.
+func (p *streamJSONParser) parseLine(line []byte) {
+	var ev resultEvent
+	if err := json.Unmarshal(bytes.TrimSpace(line), &ev); err != nil || ev.Type != "result" {
+		return
+	}
+	p.parsed = true
+	p.usage = state.Usage{
+		CostUSD:             ev.TotalCostUSD,
+		InputTokens:         ev.Usage.InputTokens,
+		OutputTokens:        ev.Usage.OutputTokens,
+		CacheReadTokens:     ev.Usage.CacheReadInputTokens,
+		CacheCreationTokens: ev.Usage.CacheCreationInputTokens,
+		Turns:               ev.NumTurns,
+		Result:              ev.Result,
+	}
+	if ev.IsError || ev.Subtype != "success" {
+		p.usage.ErrorSubtype = ev.Subtype
+	}
+}
+
+// result returns the parsed usage, and false when the agent wrote no result
+// event. A final line without a trailing newline is parsed too.
+func (p *streamJSONParser) result() (state.Usage, bool) {
+	if len(p.partial) > 0 {
+		p.parseLine(p.partial)
+		p.partial = nil
+	}
+	return p.usage, p.parsed
+}
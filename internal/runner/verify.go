@@ -0,0 +1,70 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/re-cinq/assembly-line/internal/config"
+	"github.com/re-cinq/assembly-line/internal/gate"
+	"github.com/re-cinq/assembly-line/internal/git"
+	"github.com/re-cinq/assembly-line/internal/provenance"
+)
+
+// runVerify runs a station's verify commands in its worktree once the agent
+// has succeeded (RUN-31), failing on the first command that exits non-zero.
+// Their output goes to the terminal and the station log.
+func runVerify(wtPath string, commands []string, log io.Writer) error {
+	for _, command := range commands {
+		fmt.Fprintf(log, "assembly-line: verify: %s\n", command)
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Dir = wtPath
+		cmd.Stdout = io.MultiWriter(os.Stdout, log)
+		cmd.Stderr = io.MultiWriter(os.Stderr, log)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("verify %q failed: %w", command, err)
+		}
+	}
+	return nil
+}
+
+// runGates runs the configured gates in a station's worktree before its
+// changes are committed (RUN-32), so agent changes meet the same bar as human
+// commits. Their output goes to the terminal and the station log.
+func runGates(wtPath string, gates []config.Gate, log io.Writer) error {
+	gs := make([]gate.Gate, len(gates))
+	for i, g := range gates {
+		gs[i] = gate.Gate{Name: g.Name, Run: g.Run}
+	}
+	return gate.RunGatesTo(gs, wtPath, io.MultiWriter(os.Stdout, log), io.MultiWriter(os.Stderr, log))
+}
+
+// rejectChanges throws away the agent's changes in a station's worktree,
+// leaving its branch at baseRef (RUN-31). With quarantine, the changes are
+// first committed, with their provenance trailers (TRC-1), and kept on the
+// station's quarantine ref.
+func rejectChanges(wtPath, station, baseRef string, quarantine bool, trailers provenance.Trailers, log io.Writer) error {
+	if quarantine {
+		msg := fmt.Sprintf("assembly-line: station %s quarantined %s", station, commitSkipMarker)
+		if err := git.CommitAll(wtPath, provenance.Append(msg, trailers)); err != nil {
+			return fmt.Errorf("quarantining changes: %w", err)
+		}
+		head, err := git.Run(wtPath, "rev-parse", "HEAD")
+		if err != nil {
+			return err
+		}
+		if head != baseRef {
+			ref := git.QuarantineRef(station)
+			if err := git.UpdateRef(wtPath, ref, head); err != nil {
+				return fmt.Errorf("quarantining changes: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "station %s: changes kept on %s\n", station, ref)
+			fmt.Fprintf(log, "assembly-line: changes kept on %s\n", ref)
+		}
+	}
+	if err := git.ResetHard(wtPath, baseRef); err != nil {
+		return err
+	}
+	return git.CleanAll(wtPath)
+}
@@ -0,0 +1,180 @@
+package secrets
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Rule detects one kind of secret.
+type Rule struct {
+	Name    string
+	Pattern string
+
+	// MinEntropy, when positive, is the Shannon entropy in bits per
+	// character that the secret (the pattern's first group, or else the
+	// whole match) must reach. It filters out placeholders like "changeme".
+	MinEntropy float64
+}
+
+// Builtin are the rules every scanner applies.
+var Builtin = []Rule{
+	{Name: "private key", Pattern: `-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----`},
+	{Name: "AWS access key", Pattern: `\b(AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{Name: "GitHub token", Pattern: `\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{40,})\b`},
+	{Name: "GitLab token", Pattern: `\bglpat-[A-Za-z0-9_-]{20,}\b`},
+	{Name: "Anthropic API key", Pattern: `\bsk-ant-[A-Za-z0-9_-]{32,}`},
+	{Name: "OpenAI API key", Pattern: `\bsk-(proj-)?[A-Za-z0-9_-]{20,}T3BlbkFJ[A-Za-z0-9_-]{20,}`},
+	{Name: "Slack token", Pattern: `\bxox[abposr]-[A-Za-z0-9-]{10,}`},
+	{Name: "Stripe secret key", Pattern: `\b[rs]k_live_[A-Za-z0-9]{24,}\b`},
+	{Name: "Google API key", Pattern: `\bAIza[0-9A-Za-z_-]{35}\b`},
+	{
+		Name:       "generic secret",
+		Pattern:    `(?i)(?:api[_-]?key|secret|token|passw(?:or)?d|credential)s?["']?\s*[:=]\s*["']?([A-Za-z0-9+/_.~-]{16,})`,
+		MinEntropy: 3.5,
+	},
+}
+
+// Finding is a secret found in an added line.
+type Finding struct {
+	File string
+	Line int
+	Rule string
+
+	secret string
+}
+
+// String describes the finding without revealing the secret, e.g.
+// "config.go:12: AWS access key (AKIA…)".
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", f.File, f.Line, f.Rule, redact(f.secret))
+}
+
+// redact keeps just enough of a secret to identify it.
+func redact(secret string) string {
+	if len(secret) <= 8 {
+		return "…"
+	}
+	return secret[:4] + "…"
+}
+
+// Scanner finds secrets in diffs.
+type Scanner struct {
+	rules []compiledRule
+	allow []*regexp.Regexp
+}
+
+type compiledRule struct {
+	Rule
+	re *regexp.Regexp
+}
+
+// New returns a scanner applying the built-in rules and the given extra
+// rules. Findings whose file path or secret matches an allow regular
+// expression are ignored.
+func New(extra []Rule, allow []string) (*Scanner, error) {
+	s := &Scanner{}
+	for _, r := range append(append([]Rule{}, Builtin...), extra...) {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", r.Name, err)
+		}
+		s.rules = append(s.rules, compiledRule{Rule: r, re: re})
+	}
+	for _, a := range allow {
+		re, err := regexp.Compile(a)
+		if err != nil {
+			return nil, fmt.Errorf("allow %q: %w", a, err)
+		}
+		s.allow = append(s.allow, re)
+	}
+	return s, nil
+}
+
+// ScanDiff returns the secrets in the lines a unified diff adds.
+func (s *Scanner) ScanDiff(diff string) []Finding {
+	var (
+		findings []Finding
+		file     string
+		line     int
+		inHeader bool
+	)
+	for _, text := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			file, inHeader = "", true
+		case inHeader && strings.HasPrefix(text, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+		case strings.HasPrefix(text, "@@ "):
+			inHeader = false
+			line = hunkStart(text)
+		case inHeader:
+		case strings.HasPrefix(text, "+"):
+			findings = append(findings, s.scanLine(file, line, text[1:])...)
+			line++
+		case strings.HasPrefix(text, " "):
+			line++
+		}
+	}
+	return findings
+}
+
+// hunkStart returns the first new-file line number of a hunk header such as
+// "@@ -3,2 +4,5 @@".
+func hunkStart(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 3 {
+		return 0
+	}
+	start, _, _ := strings.Cut(strings.TrimPrefix(fields[2], "+"), ",")
+	n, _ := strconv.Atoi(start)
+	return n
+}
+
+// scanLine returns the secrets in one added line.
+func (s *Scanner) scanLine(file string, line int, text string) []Finding {
+	var findings []Finding
+	for _, r := range s.rules {
+		for _, m := range r.re.FindAllStringSubmatch(text, -1) {
+			secret := m[0]
+			if len(m) > 1 && m[1] != "" && r.MinEntropy > 0 {
+				secret = m[1]
+			}
+			if r.MinEntropy > 0 && entropy(secret) < r.MinEntropy {
+				continue
+			}
+			if s.allowed(file, m[0]) {
+				continue
+			}
+			findings = append(findings, Finding{File: file, Line: line, Rule: r.Name, secret: secret})
+		}
+	}
+	return findings
+}
+
+// allowed reports whether the allow-list exempts a finding.
+func (s *Scanner) allowed(file, match string) bool {
+	for _, re := range s.allow {
+		if re.MatchString(file) || re.MatchString(match) {
+			return true
+		}
+	}
+	return false
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var h float64
+	n := float64(len([]rune(s)))
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
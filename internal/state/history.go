@@ -0,0 +1,207 @@
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	historyFile = "history.jsonl"
+	runsDir     = "runs" // records of runs in progress (HIST-4)
+)
+
+// Station run outcomes recorded in the history ledger.
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	OutcomeTimedOut  = "timed out"
+	OutcomeSkipped   = "skipped"
+
+	// OutcomeInterrupted is recorded for a station still running when its
+	// run was stopped (HIST-4).
+	OutcomeInterrupted = "interrupted"
+)
+
+// Outcomes lists every station run outcome.
+var Outcomes = []string{OutcomeSucceeded, OutcomeFailed, OutcomeTimedOut, OutcomeSkipped, OutcomeInterrupted}
+
+// StationRun records what happened to one station during a line run.
+type StationRun struct {
+	Name       string `json:"name"`
+	Outcome    string `json:"outcome"`
+	ExitCode   int    `json:"exit_code"`
+	Attempts   int    `json:"attempts"`
+	DurationMS int64  `json:"duration_ms"`
+	Commit     string `json:"commit,omitempty"`
+	Agent      string `json:"agent,omitempty"`   // agent actually used (RUN-28)
+	Usage      *Usage `json:"usage,omitempty"`   // parsed from agent output (RUN-29)
+	Summary    string `json:"summary,omitempty"` // the agent's summary of its commit (RUN-36)
+}
+
+// Usage records what a station's agent consumed and reported, as parsed from
+// its output (RUN-29). Costs and tokens are summed over attempts.
+type Usage struct {
+	CostUSD             float64 `json:"cost_usd"`
+	InputTokens         int64   `json:"input_tokens"`
+	OutputTokens        int64   `json:"output_tokens"`
+	CacheReadTokens     int64   `json:"cache_read_tokens,omitempty"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens,omitempty"`
+	Turns               int     `json:"turns,omitempty"`
+	Result              string  `json:"result,omitempty"`
+	ErrorSubtype        string  `json:"error_subtype,omitempty"`
+}
+
+// Tokens returns the total number of tokens used.
+func (u Usage) Tokens() int64 {
+	return u.InputTokens + u.OutputTokens + u.CacheReadTokens + u.CacheCreationTokens
+}
+
+// Add adds another agent run's usage to u. The result text and error subtype
+// are taken from the later run.
+func (u *Usage) Add(o Usage) {
+	u.CostUSD += o.CostUSD
+	u.InputTokens += o.InputTokens
+	u.OutputTokens += o.OutputTokens
+	u.CacheReadTokens += o.CacheReadTokens
+	u.CacheCreationTokens += o.CacheCreationTokens
+	u.Turns += o.Turns
+	u.Result = o.Result
+	u.ErrorSubtype = o.ErrorSubtype
+}
+
+// Run records a single line run in the history ledger.
+type Run struct {
+	ID       string       `json:"id"`
+	Commit   string       `json:"commit"`
+	Start    time.Time    `json:"start"`
+	End      time.Time    `json:"end"`
+	Stations []StationRun `json:"stations"`
+
+	// Interrupted is set for a run that was stopped before it finished,
+	// e.g. by a newer run (RUN-11). End is when it was last updated.
+	Interrupted bool `json:"interrupted,omitempty"`
+}
+
+// AppendHistory appends a run record to the history ledger. The ledger is
+// append-only JSON Lines: one run per line, oldest first.
+func AppendHistory(repoDir string, run Run) error {
+	if err := ensureDir(repoDir); err != nil {
+		return err
+	}
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("marshaling run: %w", err)
+	}
+	path := filepath.Join(repoDir, stateDir, historyFile)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// runInProgressPath returns the path of a running run's record.
+func runInProgressPath(repoDir, id string) string {
+	return filepath.Join(repoDir, stateDir, runsDir, id+".json")
+}
+
+// WriteRunInProgress records a run that has not finished yet, replacing any
+// earlier record of it (HIST-4). Should the run never finish,
+// FlushInterruptedRuns moves the record to the ledger.
+func WriteRunInProgress(repoDir string, run Run) error {
+	if err := ensureDir(repoDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(repoDir, stateDir, runsDir), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("marshaling run: %w", err)
+	}
+	// Replace the record atomically, so it is never seen half-written
+	path := runInProgressPath(repoDir, run.ID)
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// RemoveRunInProgress removes the record of a run once it has finished.
+func RemoveRunInProgress(repoDir, id string) error {
+	return removeFile(runInProgressPath(repoDir, id))
+}
+
+// FlushInterruptedRuns appends the records of runs that were stopped before
+// they finished (RUN-11) to the ledger, marked interrupted, and removes them
+// (HIST-4). It must only be called while no other run is in progress.
+func FlushInterruptedRuns(repoDir string) error {
+	dir := filepath.Join(repoDir, stateDir, runsDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	runs, err := ReadHistory(repoDir)
+	if err != nil {
+		return err
+	}
+	recorded := make(map[string]bool, len(runs))
+	for _, run := range runs {
+		recorded[run.ID] = true
+	}
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if filepath.Ext(e.Name()) != ".json" {
+			_ = removeFile(path) // a stopped run's partial write
+			continue
+		}
+		var run Run
+		data, err := os.ReadFile(path)
+		if err == nil && json.Unmarshal(data, &run) == nil && !recorded[run.ID] {
+			run.Interrupted = true
+			if err := AppendHistory(repoDir, run); err != nil {
+				return err
+			}
+		}
+		if err := removeFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadHistory returns all recorded runs, oldest first. Returns nil if no
+// ledger exists. Lines that cannot be parsed are skipped.
+func ReadHistory(repoDir string) ([]Run, error) {
+	f, err := os.Open(filepath.Join(repoDir, stateDir, historyFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs []Run
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, scanner.Err()
+}
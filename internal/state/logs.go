@@ -0,0 +1,75 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	logsDir   = "logs"
+	logSuffix = ".log"
+
+	// maxStationLogs is the number of run logs kept per station.
+	maxStationLogs = 20
+)
+
+// stationLogsDir returns the directory holding a station's run logs.
+func stationLogsDir(repoDir, stationName string) string {
+	return filepath.Join(repoDir, stateDir, logsDir, stationName)
+}
+
+// StationLogPath returns the path of the log file for a station's run.
+func StationLogPath(repoDir, stationName, runID string) string {
+	return filepath.Join(stationLogsDir(repoDir, stationName), runID+logSuffix)
+}
+
+// CreateStationLog creates (truncating) the log file for a station's run and
+// prunes the oldest logs so that at most maxStationLogs are kept.
+func CreateStationLog(repoDir, stationName, runID string) (*os.File, error) {
+	if err := ensureDir(repoDir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(stationLogsDir(repoDir, stationName), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(StationLogPath(repoDir, stationName, runID))
+	if err != nil {
+		return nil, err
+	}
+	pruneStationLogs(repoDir, stationName)
+	return f, nil
+}
+
+// ListStationLogs returns the run IDs that have a log for the given station,
+// oldest first. Run IDs sort chronologically.
+func ListStationLogs(repoDir, stationName string) ([]string, error) {
+	entries, err := os.ReadDir(stationLogsDir(repoDir, stationName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var runIDs []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), logSuffix) {
+			continue
+		}
+		runIDs = append(runIDs, strings.TrimSuffix(e.Name(), logSuffix))
+	}
+	sort.Strings(runIDs)
+	return runIDs, nil
+}
+
+// pruneStationLogs removes the oldest logs beyond maxStationLogs.
+func pruneStationLogs(repoDir, stationName string) {
+	runIDs, err := ListStationLogs(repoDir, stationName)
+	if err != nil || len(runIDs) <= maxStationLogs {
+		return
+	}
+	for _, id := range runIDs[:len(runIDs)-maxStationLogs] {
+		_ = removeFile(StationLogPath(repoDir, stationName, id))
+	}
+}
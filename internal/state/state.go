@@ -13,16 +13,29 @@ const (
 	stateDir    = ".line"
 	pidFile     = "run.pid"
 	stationsDir = "stations"
+	budgetFile  = "budget-exceeded"
 )
 
-// ensureDir creates the .line directory if it doesn't exist.
+// ensureDir creates the .line directory if it doesn't exist. The directory
+// ignores itself so that runtime state (logs, history) is never committed,
+// even in repos where line init has not added it to .gitignore.
 func ensureDir(repoDir string) error {
 	dir := filepath.Join(repoDir, stateDir)
-	return os.MkdirAll(dir, 0o755)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	ignorePath := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignorePath); os.IsNotExist(err) {
+		return os.WriteFile(ignorePath, []byte("*\n"), 0o644)
+	}
+	return nil
 }
 
 // ensureStationsDir creates the .line/stations directory.
 func ensureStationsDir(repoDir string) error {
+	if err := ensureDir(repoDir); err != nil {
+		return err
+	}
 	return os.MkdirAll(filepath.Join(repoDir, stateDir, stationsDir), 0o755)
 }
 
@@ -110,6 +123,32 @@ func RemoveStationPID(repoDir, stationName string) error {
 	return removeFile(stationFilePath(repoDir, stationName, ".pid"))
 }
 
+// WriteStationRunning marks a station as running under the runner with the
+// given PID, for the whole station run: between agent attempts too, when no
+// agent PID is recorded.
+func WriteStationRunning(repoDir, stationName string, runnerPID int) error {
+	if err := ensureStationsDir(repoDir); err != nil {
+		return err
+	}
+	return os.WriteFile(stationFilePath(repoDir, stationName, ".running"), []byte(strconv.Itoa(runnerPID)), 0o644)
+}
+
+// IsStationRunning reports whether a station is marked as running by a
+// runner that is still alive.
+func IsStationRunning(repoDir, stationName string) bool {
+	data, err := os.ReadFile(stationFilePath(repoDir, stationName, ".running"))
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return err == nil && pid > 0 && IsProcessRunning(pid)
+}
+
+// RemoveStationRunning removes a station's running marker.
+func RemoveStationRunning(repoDir, stationName string) error {
+	return removeFile(stationFilePath(repoDir, stationName, ".running"))
+}
+
 // KillAllStationAgents kills all running station agent processes and removes
 // their PID files. Agents run in their own process groups (Setpgid), so each
 // must be killed individually via its process group.
@@ -132,21 +171,148 @@ func KillAllStationAgents(repoDir string) {
 	}
 }
 
-// WriteStationFailed writes a marker indicating a station's agent failed.
-func WriteStationFailed(repoDir, stationName string) error {
+// WriteStationAttempt records which attempt (1-based, out of max) a station's
+// agent is on. Format: "ATTEMPT MAX" (e.g., "2 3")
+func WriteStationAttempt(repoDir, stationName string, attempt, max int) error {
 	if err := ensureStationsDir(repoDir); err != nil {
 		return err
 	}
-	return os.WriteFile(stationFilePath(repoDir, stationName, ".failed"), []byte("1"), 0o644)
+	content := fmt.Sprintf("%d %d", attempt, max)
+	return os.WriteFile(stationFilePath(repoDir, stationName, ".attempt"), []byte(content), 0o644)
 }
 
-// ReadStationFailed returns true if a station has a failure marker.
-func ReadStationFailed(repoDir, stationName string) bool {
-	_, err := os.Stat(stationFilePath(repoDir, stationName, ".failed"))
-	return err == nil
+// ReadStationAttempt returns the most recent attempt and the maximum number
+// of attempts for a station. Returns zeros if nothing has been recorded.
+func ReadStationAttempt(repoDir, stationName string) (int, int) {
+	data, err := os.ReadFile(stationFilePath(repoDir, stationName, ".attempt"))
+	if err != nil {
+		return 0, 0
+	}
+	var attempt, max int
+	if _, err := fmt.Sscanf(string(data), "%d %d", &attempt, &max); err != nil {
+		return 0, 0
+	}
+	return attempt, max
+}
+
+// Failure reasons recorded in a station's failure marker.
+const (
+	FailReasonFailed   = "failed"
+	FailReasonTimedOut = "timed out"
+	FailReasonBudget   = "budget exceeded" // not started (RUN-30)
+)
+
+// WriteStationFailed writes a marker indicating a station's agent failed,
+// recording why (one of the FailReason constants).
+func WriteStationFailed(repoDir, stationName, reason string) error {
+	if err := ensureStationsDir(repoDir); err != nil {
+		return err
+	}
+	return os.WriteFile(stationFilePath(repoDir, stationName, ".failed"), []byte(reason), 0o644)
+}
+
+// ReadStationFailure returns the recorded failure reason and true if a
+// station has a failure marker. Markers without a known reason read as
+// FailReasonFailed.
+func ReadStationFailure(repoDir, stationName string) (string, bool) {
+	data, err := os.ReadFile(stationFilePath(repoDir, stationName, ".failed"))
+	if err != nil {
+		return "", false
+	}
+	reason := strings.TrimSpace(string(data))
+	if reason != FailReasonTimedOut && reason != FailReasonBudget {
+		reason = FailReasonFailed
+	}
+	return reason, true
 }
 
 // RemoveStationFailed removes a station's failure marker.
 func RemoveStationFailed(repoDir, stationName string) error {
 	return removeFile(stationFilePath(repoDir, stationName, ".failed"))
 }
+
+// WriteBudgetExceeded records why the line stopped starting stations
+// (RUN-30).
+func WriteBudgetExceeded(repoDir, reason string) error {
+	if err := ensureDir(repoDir); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(repoDir, stateDir, budgetFile), []byte(reason), 0o644)
+}
+
+// ReadBudgetExceeded returns the reason the last run stopped for its budget,
+// and true if it did.
+func ReadBudgetExceeded(repoDir string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(repoDir, stateDir, budgetFile))
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(data)), true
+}
+
+// RemoveBudgetExceeded removes the budget exceeded marker.
+func RemoveBudgetExceeded(repoDir string) error {
+	return removeFile(filepath.Join(repoDir, stateDir, budgetFile))
+}
+
+// WriteStationOutOfScope records the files a station's agent changed outside
+// its allowed paths (RUN-33), removing the record when there are none.
+func WriteStationOutOfScope(repoDir, stationName string, files []string) error {
+	path := stationFilePath(repoDir, stationName, ".scope")
+	if len(files) == 0 {
+		return removeFile(path)
+	}
+	if err := ensureStationsDir(repoDir); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(files, "\n")), 0o644)
+}
+
+// ReadStationOutOfScope returns the files a station's agent last changed
+// outside its allowed paths.
+func ReadStationOutOfScope(repoDir, stationName string) []string {
+	data, err := os.ReadFile(stationFilePath(repoDir, stationName, ".scope"))
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+// WriteStationAgent records the agent a station last ran (RUN-28): its
+// profile name, or its command.
+func WriteStationAgent(repoDir, stationName, agent string) error {
+	if err := ensureStationsDir(repoDir); err != nil {
+		return err
+	}
+	return os.WriteFile(stationFilePath(repoDir, stationName, ".agent"), []byte(agent), 0o644)
+}
+
+// ReadStationAgent returns the agent a station last ran, or "" if none has
+// been recorded.
+func ReadStationAgent(repoDir, stationName string) string {
+	data, err := os.ReadFile(stationFilePath(repoDir, stationName, ".agent"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// WriteStationSkipped writes a marker indicating a station was skipped
+// because its path filters did not match the triggering commit.
+func WriteStationSkipped(repoDir, stationName string) error {
+	if err := ensureStationsDir(repoDir); err != nil {
+		return err
+	}
+	return os.WriteFile(stationFilePath(repoDir, stationName, ".skipped"), nil, 0o644)
+}
+
+// IsStationSkipped returns true if a station has a skipped marker.
+func IsStationSkipped(repoDir, stationName string) bool {
+	_, err := os.Stat(stationFilePath(repoDir, stationName, ".skipped"))
+	return err == nil
+}
+
+// RemoveStationSkipped removes a station's skipped marker.
+func RemoveStationSkipped(repoDir, stationName string) error {
+	return removeFile(stationFilePath(repoDir, stationName, ".skipped"))
+}